@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// activity holds the release age and recent work of a module, for the
+// released, age, commit and contributors columns and the unreleased age of
+// the Latest column.
+type activity struct {
+	// Released is the commit date of the latest tag.
+	Released time.Time
+
+	// LastCommit is the date of the latest commit to the module.
+	LastCommit time.Time
+
+	// Unreleased is the date of the oldest commit since the latest tag,
+	// zero when there is none.
+	Unreleased time.Time
+
+	// Contributors counts the authors of the commits since the latest tag.
+	Contributors int
+}
+
+// readActivity reads the activity of the module in dir, whose latest tag is
+// tag, empty when it has none. Commits are read within the pathspec spec of
+// the module, so the commits of nested modules are left to them.
+func readActivity(dir string, spec []string, tag string) activity {
+	var a activity
+	if out := gitOutput(dir, append([]string{"log", "-1", "--format=%cI", "HEAD"}, spec...)...); out != "" {
+		a.LastCommit, _ = time.Parse(time.RFC3339, out)
+	}
+	if tag == "" {
+		return a
+	}
+	if out := gitOutput(dir, "log", "-1", "--format=%cI", tag); out != "" {
+		a.Released, _ = time.Parse(time.RFC3339, out)
+	}
+
+	authors := make(map[string]bool)
+	out := gitOutput(dir, append([]string{"log", "--format=%cI%x09%aE", tag + "..HEAD"}, spec...)...)
+	for _, line := range strings.Split(out, "\n") {
+		date, author, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		// The log is newest first, so the last date read is the oldest.
+		a.Unreleased, _ = time.Parse(time.RFC3339, date)
+		authors[strings.ToLower(author)] = true
+	}
+	a.Contributors = len(authors)
+	return a
+}
+
+// readsActivity reports whether the table of opts shows the activity of the
+// modules, so it is worth the git commands reading it: the released, age,
+// commit and contributors columns, and the Latest column when it flags
+// unreleased commits. No --where field reads the activity.
+func readsActivity(opts *Options) bool {
+	for _, column := range opts.Columns {
+		switch column {
+		case "released", "age", "commit", "contributors":
+			return true
+		case "latest":
+			if opts.UnreleasedAge > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gitOutput runs git in dir and returns its trimmed output, empty when it
+// fails.
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// unreleasedStale reports whether the commits a module holds since its
+// latest tag have waited longer than age for a release. A zero age never
+// flags them.
+func unreleasedStale(a activity, age time.Duration, now time.Time) bool {
+	return age > 0 && !a.Unreleased.IsZero() && now.Sub(a.Unreleased) > age
+}
+
+// daysSince returns the whole days from t to now.
+func daysSince(t, now time.Time) int {
+	return int(now.Sub(t).Hours() / 24)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// commitAt commits every change in dir as author, dated date.
+func commitAt(t *testing.T, dir, author, date, message string) {
+	t.Helper()
+	runGit(t, dir, "add", "-A")
+	cmd := exec.Command("git", "-c", "user.email="+author, "-c", "user.name=test", "commit", "--quiet", "-m", message)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, output)
+	}
+}
+
+func TestReadActivity(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.26\n")
+	runGit(t, root, "init", "--quiet")
+	commitAt(t, root, "ana@example.com", "2026-01-10T12:00:00Z", "first version")
+	runGit(t, root, "tag", "v0.1.0")
+
+	writeTestFile(t, filepath.Join(root, "main.go"), "package main\n")
+	commitAt(t, root, "bo@example.com", "2026-02-01T12:00:00Z", "add main")
+	writeTestFile(t, filepath.Join(root, "main.go"), "package main\n\nfunc main() {}\n")
+	commitAt(t, root, "Ana@example.com", "2026-03-05T12:00:00Z", "add func main")
+
+	a := readActivity(root, modulePathspec(root), "v0.1.0")
+	want := activity{
+		Released:     time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC),
+		LastCommit:   time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC),
+		Unreleased:   time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC),
+		Contributors: 2,
+	}
+	if !a.Released.Equal(want.Released) || !a.LastCommit.Equal(want.LastCommit) || !a.Unreleased.Equal(want.Unreleased) || a.Contributors != want.Contributors {
+		t.Fatalf("readActivity() = %+v, want %+v", a, want)
+	}
+
+	// Without a tag only the last commit is known.
+	if a := readActivity(root, modulePathspec(root), ""); !a.LastCommit.Equal(want.LastCommit) || !a.Released.IsZero() || a.Contributors != 0 {
+		t.Fatalf("readActivity(no tag) = %+v, want only the last commit", a)
+	}
+}
+
+func TestUnreleasedStale(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	waiting := activity{Unreleased: now.AddDate(0, 0, -100)}
+	tests := []struct {
+		a    activity
+		age  time.Duration
+		want bool
+	}{
+		{waiting, 90 * 24 * time.Hour, true},
+		{waiting, 180 * 24 * time.Hour, false},
+		{waiting, 0, false},
+		{activity{}, 24 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		if got := unreleasedStale(tt.a, tt.age, now); got != tt.want {
+			t.Errorf("unreleasedStale(%v, %v) = %v, want %v", tt.a.Unreleased, tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTablesActivity(t *testing.T) {
+	now := time.Now()
+	modules := []moduleInfo{{
+		Name:   "example.com/app",
+		Path:   "./app",
+		Latest: "v0.1.0",
+		Activity: activity{
+			Released:     now.AddDate(0, 0, -200),
+			LastCommit:   time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC),
+			Unreleased:   now.AddDate(0, 0, -120),
+			Contributors: 3,
+		},
+		GitState: &components.Git{BranchName: "main", Ahead: 4},
+	}}
+
+	var out strings.Builder
+	opts := &Options{All: true, Columns: []string{"module", "latest", "age", "commit", "contributors"}, UnreleasedAge: 90 * 24 * time.Hour}
+	renderTables(&out, modules, opts, false)
+	if want := "| ./app | v0.1.0 | 200 days | 2026-03-05 | 3 |"; !strings.Contains(out.String(), want) {
+		t.Fatalf("renderTables() =\n%s\nwant it to hold %q", out.String(), want)
+	}
+
+	out.Reset()
+	renderTables(&out, modules, opts, true)
+	if want := components.ColorAmber + "v0.1.0"; !strings.Contains(out.String(), want) {
+		t.Fatalf("renderTables() did not color the stale release amber:\n%q", out.String())
+	}
+	out.Reset()
+	opts.UnreleasedAge = 0
+	renderTables(&out, modules, opts, true)
+	if want := components.ColorWhite + "v0.1.0"; !strings.Contains(out.String(), want) {
+		t.Fatalf("renderTables() colored a release with flagging off:\n%q", out.String())
+	}
+}
+
+func TestReadsActivity(t *testing.T) {
+	tests := []struct {
+		columns []string
+		age     time.Duration
+		want    bool
+	}{
+		{[]string{"module", "branch", "go"}, time.Hour, false},
+		{[]string{"module", "latest"}, 0, false},
+		{[]string{"module", "latest"}, time.Hour, true},
+		{[]string{"module", "contributors"}, 0, true},
+	}
+	for _, tt := range tests {
+		if got := readsActivity(&Options{Columns: tt.columns, UnreleasedAge: tt.age}); got != tt.want {
+			t.Errorf("readsActivity(%q, %v) = %v, want %v", tt.columns, tt.age, got, tt.want)
+		}
+	}
+}
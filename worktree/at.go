@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// atLayouts are the date forms --at takes; anything else names a git ref.
+var atLayouts = []string{"2006-01-02", "2006-01-02T15:04", time.RFC3339}
+
+// parseAtDate reads an --at value as a date, reporting false when it names a
+// git ref instead. A date without a zone is local time.
+func parseAtDate(value string) (time.Time, bool) {
+	for _, layout := range atLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// resolveAt returns the commit of the git repository holding dir that --at
+// names: the last commit before a date on the default branch, picked as
+// defaultBranch does with names, or a ref such as a tag or a branch. A
+// repository without a default branch is read from HEAD.
+func resolveAt(dir, at string, names []string) (string, error) {
+	if date, ok := parseAtDate(at); ok {
+		// The default branch rather than HEAD, so a repository sitting on a
+		// feature branch still reports what was built from the trunk.
+		ref := "HEAD"
+		if trunk := defaultBranch(dir, names); trunk != "" {
+			if r := trunkRef(dir, trunk); r != "" {
+				ref = r
+			}
+		}
+		commit := gitOutput(dir, "rev-list", "-1", "--before="+date.Format(time.RFC3339), ref)
+		if commit == "" {
+			return "", fmt.Errorf("no commit before %s", at)
+		}
+		return commit, nil
+	}
+	commit := gitOutput(dir, "rev-parse", "--verify", "--quiet", at+"^{commit}")
+	if commit == "" {
+		return "", fmt.Errorf("no ref %s", at)
+	}
+	return commit, nil
+}
+
+// moduleAt is the state of a module at a past commit of its repository.
+type moduleAt struct {
+	name      string
+	dir       string
+	commit    string
+	date      time.Time
+	latest    string
+	goVersion string
+	requires  []requireInfo
+
+	// err says why the module has no state then, such as a go.mod that did
+	// not exist yet.
+	err error
+}
+
+// readModuleAt reads the module in dir as it was at the commit --at names:
+// its go.mod through git show rather than the working tree, and the highest
+// version tagged by then.
+func readModuleAt(dir, modPath, at string, names []string) moduleAt {
+	m := moduleAt{name: modPath, dir: dir}
+	commit, err := resolveAt(dir, at, names)
+	if err != nil {
+		m.err = err
+		return m
+	}
+	m.commit = commit
+	if out := gitOutput(dir, "log", "-1", "--format=%cI", commit); out != "" {
+		m.date, _ = time.Parse(time.RFC3339, out)
+	}
+
+	data := gitOutput(dir, "show", commit+":./go.mod")
+	if data == "" {
+		m.err = fmt.Errorf("no go.mod at %s", shortCommit(commit))
+		return m
+	}
+	mod, err := modfile.Parse("go.mod", []byte(data), nil)
+	if err != nil {
+		m.err = err
+		return m
+	}
+	if mod.Go != nil {
+		m.goVersion = mod.Go.Version
+	}
+	for _, r := range mod.Require {
+		m.requires = append(m.requires, requireInfo{path: r.Mod.Path, version: r.Mod.Version})
+	}
+	m.latest = latestTagMerged(dir, gitTagPrefix(dir, modPath), pathMajor(modPath), commit)
+	return m
+}
+
+// shortCommit abbreviates a commit hash the way git log --oneline does.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// collectAt reads every module as it was at the --at date or ref.
+func collectAt(mods []string, modPaths map[string]string, at string, names []string) []moduleAt {
+	var out []moduleAt
+	for _, mod := range mods {
+		out = append(out, readModuleAt(modPaths[mod], mod, at, names))
+	}
+	return out
+}
+
+// renderAt writes the workspace as it was at the --at date or ref: the
+// commit each module was at, its latest tag and go directive then, and the
+// workspace modules it required, in yellow when not at their latest tag of
+// the time.
+func renderAt(w io.Writer, modules []moduleAt, styled bool) {
+	latest := make(map[string]string)
+	for _, m := range modules {
+		latest[m.name] = m.latest
+	}
+
+	var rows [][]string
+	for _, m := range modules {
+		if m.err != nil {
+			rows = append(rows, []string{
+				relPath(m.dir),
+				components.ShortPath(m.name),
+				colorLines(m.err.Error(), components.ColorSeparator, styled),
+				"", "", "",
+			})
+			continue
+		}
+
+		commit := shortCommit(m.commit)
+		if !m.date.IsZero() {
+			commit += " " + m.date.Format("2006-01-02")
+		}
+
+		var requires []string
+		sort.Slice(m.requires, func(i, j int) bool { return m.requires[i].path < m.requires[j].path })
+		for _, r := range m.requires {
+			tag, ok := latest[r.path]
+			if !ok {
+				continue
+			}
+			color := components.ColorGreen
+			if tag != "" && r.version != tag {
+				color = components.ColorYellow
+			}
+			requires = append(requires, components.ShortPath(r.path)+" "+colorLines(r.version, color, styled))
+		}
+
+		rows = append(rows, []string{
+			relPath(m.dir),
+			components.ShortPath(m.name),
+			colorLines(commit, components.ColorBorder, styled),
+			colorLines(m.latest, components.ColorWhite, styled),
+			colorLines(m.goVersion, components.ColorTeal, styled),
+			strings.Join(requires, "\n"),
+		})
+	}
+	writeSimpleTable(w, []string{"Path", "Module", "Commit", "Latest", "Go", "Requires"}, rows, styled)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// atWorkspace writes two repositories: lib, released as v1.0.0 in January
+// and v1.1.0 in March, and app, requiring lib v1.0.0 from January and
+// moving to v1.1.0 and go 1.26 in July.
+func atWorkspace(t *testing.T) map[string]string {
+	t.Helper()
+	root := t.TempDir()
+	lib := filepath.Join(root, "lib")
+	app := filepath.Join(root, "app")
+
+	writeTestFile(t, filepath.Join(lib, "go.mod"), "module example.com/lib\n\ngo 1.24\n")
+	runGit(t, lib, "init", "--quiet")
+	commitAt(t, lib, "ana@example.com", "2026-01-05T12:00:00Z", "first version")
+	runGit(t, lib, "tag", "v1.0.0")
+	writeTestFile(t, filepath.Join(lib, "lib.go"), "package lib\n")
+	commitAt(t, lib, "ana@example.com", "2026-03-05T12:00:00Z", "add lib.go")
+	runGit(t, lib, "tag", "v1.1.0")
+
+	writeTestFile(t, filepath.Join(app, "go.mod"), "module example.com/app\n\ngo 1.24\n\nrequire example.com/lib v1.0.0\n")
+	runGit(t, app, "init", "--quiet")
+	commitAt(t, app, "bo@example.com", "2026-01-10T12:00:00Z", "first version")
+	writeTestFile(t, filepath.Join(app, "go.mod"), "module example.com/app\n\ngo 1.26\n\nrequire example.com/lib v1.1.0\n")
+	commitAt(t, app, "bo@example.com", "2026-07-01T12:00:00Z", "deps: bump lib")
+	runGit(t, app, "tag", "v0.2.0")
+
+	return map[string]string{"example.com/app": app, "example.com/lib": lib}
+}
+
+func TestParseAtDate(t *testing.T) {
+	for _, value := range []string{"2026-06-01", "2026-06-01T08:30", "2026-06-01T08:30:00Z"} {
+		if _, ok := parseAtDate(value); !ok {
+			t.Errorf("parseAtDate(%q) is not a date", value)
+		}
+	}
+	for _, value := range []string{"v1.2.0", "main", "HEAD~3", "06/01/2026"} {
+		if _, ok := parseAtDate(value); ok {
+			t.Errorf("parseAtDate(%q) read a ref as a date", value)
+		}
+	}
+}
+
+func TestReadModuleAt(t *testing.T) {
+	modPaths := atWorkspace(t)
+	mods := []string{"example.com/app", "example.com/lib"}
+
+	modules := collectAt(mods, modPaths, "2026-06-01", nil)
+	app, lib := modules[0], modules[1]
+	if app.err != nil || app.goVersion != "1.24" || app.latest != "" || len(app.requires) != 1 || app.requires[0].version != "v1.0.0" {
+		t.Fatalf("readModuleAt(app) = %+v, want go 1.24 requiring lib v1.0.0", app)
+	}
+	if !app.date.Equal(time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("readModuleAt(app) date = %v, want the January commit", app.date)
+	}
+	if lib.err != nil || lib.latest != "v1.1.0" {
+		t.Fatalf("readModuleAt(lib) = %+v, want v1.1.0 released", lib)
+	}
+
+	// In February lib v1.1.0 was not out yet, and before January nothing was.
+	if lib := readModuleAt(modPaths["example.com/lib"], "example.com/lib", "2026-02-01", nil); lib.latest != "v1.0.0" {
+		t.Fatalf("readModuleAt(lib, February) latest = %q, want v1.0.0", lib.latest)
+	}
+	if lib := readModuleAt(modPaths["example.com/lib"], "example.com/lib", "2025-12-01", nil); lib.err == nil {
+		t.Fatalf("readModuleAt(lib, December) = %+v, want no commit", lib)
+	}
+
+	// A ref names the commit directly, and is missing from the other repository.
+	if app := readModuleAt(modPaths["example.com/app"], "example.com/app", "v0.2.0", nil); app.goVersion != "1.26" || app.latest != "v0.2.0" {
+		t.Fatalf("readModuleAt(app, v0.2.0) = %+v, want go 1.26", app)
+	}
+	if lib := readModuleAt(modPaths["example.com/lib"], "example.com/lib", "v0.2.0", nil); lib.err == nil || !strings.Contains(lib.err.Error(), "no ref v0.2.0") {
+		t.Fatalf("readModuleAt(lib, v0.2.0) = %+v, want no ref", lib)
+	}
+}
+
+// TestReadModuleAtFollowsDefaultBranch checks a date is resolved on the
+// default branch, not on the feature branch a repository has checked out.
+func TestReadModuleAtFollowsDefaultBranch(t *testing.T) {
+	modPaths := atWorkspace(t)
+	app := modPaths["example.com/app"]
+	runGit(t, app, "checkout", "--quiet", "-b", "feature", "HEAD~1")
+	writeTestFile(t, filepath.Join(app, "go.mod"), "module example.com/app\n\ngo 1.25\n\nrequire example.com/lib v1.1.0\n")
+	commitAt(t, app, "bo@example.com", "2026-04-01T12:00:00Z", "try go 1.25")
+
+	if m := readModuleAt(app, "example.com/app", "2026-06-01", nil); m.goVersion != "1.24" {
+		t.Fatalf("readModuleAt(app on a feature branch) = %+v, want go 1.24 from the default branch", m)
+	}
+	if m := readModuleAt(app, "example.com/app", "2026-06-01", []string{"feature"}); m.goVersion != "1.25" {
+		t.Fatalf("readModuleAt(app, feature as the default branch) = %+v, want go 1.25", m)
+	}
+}
+
+func TestRenderAt(t *testing.T) {
+	modPaths := atWorkspace(t)
+	chdir(t, filepath.Dir(modPaths["example.com/app"]))
+	modules := collectAt([]string{"example.com/app", "example.com/lib"}, modPaths, "2026-06-01", nil)
+
+	var out strings.Builder
+	renderAt(&out, modules, false)
+	got := out.String()
+	for _, want := range []string{
+		"| Path | Module | Commit | Latest | Go | Requires |",
+		" 2026-01-10 |  | 1.24 | example.com/lib v1.0.0 |",
+		" 2026-03-05 | v1.1.0 | 1.24 |  |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("renderAt() =\n%s\nwant it to hold %q", got, want)
+		}
+	}
+
+	out.Reset()
+	renderAt(&out, modules, true)
+	if !strings.Contains(out.String(), components.ColorYellow+"v1.0.0") {
+		t.Fatalf("renderAt() did not mark lib v1.0.0 behind v1.1.0:\n%q", out.String())
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// commandBranches lists the dead branches of every repository.
+const commandBranches = "branches"
+
+// defaultStaleDays is how long a branch goes without a commit before it is
+// reported as stale.
+const defaultStaleDays = 90
+
+// staleBranch is a branch without a commit for some days.
+type staleBranch struct {
+	name string
+	days int
+}
+
+// branchReport holds the dead branches of one repository. The default branch
+// and the checked out branch are never listed, and a merged branch is not
+// listed again as gone or stale.
+type branchReport struct {
+	repo          string
+	defaultBranch string
+	merged        []string
+	gone          []string
+	stale         []staleBranch
+}
+
+// empty reports whether the repository has no dead branches.
+func (r branchReport) empty() bool {
+	return len(r.merged) == 0 && len(r.gone) == 0 && len(r.stale) == 0
+}
+
+// defaultBranch returns the branch the repository treats as its trunk: the
+// first of names the repository has, configured as scan.default_branches,
+// else the branch origin/HEAD points at, or else a local main or master.
+func defaultBranch(repo string, names []string) string {
+	for _, name := range names {
+		if trunkRef(repo, name) != "" {
+			return name
+		}
+	}
+	if ref := firstCommandLine(repo, "git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); ref != "" {
+		return strings.TrimPrefix(ref, "origin/")
+	}
+	for _, name := range []string{"main", "master"} {
+		if exec.Command("git", "-C", repo, "show-ref", "--verify", "--quiet", "refs/heads/"+name).Run() == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// trunkRef returns the ref the default branch name is read from: the one
+// fetched from origin, which --fetch keeps current, or the local branch when
+// origin has none. It returns "" when the repository has neither.
+func trunkRef(repo, name string) string {
+	for _, ref := range []string{"refs/remotes/origin/" + name, "refs/heads/" + name} {
+		if exec.Command("git", "-C", repo, "show-ref", "--verify", "--quiet", ref).Run() == nil {
+			return ref
+		}
+	}
+	return ""
+}
+
+// trunkState is how the branch checked out in a repository stands against
+// the default branch: the commits it holds that the trunk does not, the ones
+// it lacks, and whether its own commits have been merged into the trunk.
+type trunkState struct {
+	name   string
+	ahead  int
+	behind int
+	merged bool
+}
+
+// readTrunk reads how the branch checked out in repo stands against its
+// default branch, picked as defaultBranch does with names. A branch holding
+// nothing the trunk lacks is merged when the trunk took its commits with a
+// merge commit, or, fast-forwarded or rebased, when its upstream is gone. The name is left
+// empty when there is no default branch to compare with, HEAD is detached,
+// or the trunk cannot be read, so the branch is not shown against it.
+func readTrunk(repo string, names []string) trunkState {
+	name := defaultBranch(repo, names)
+	branch := getGitBranch(repo)
+	if name == "" || branch == "" || branch == "HEAD" {
+		return trunkState{}
+	}
+	if branch == name {
+		return trunkState{name: name}
+	}
+	ref := trunkRef(repo, name)
+	if ref == "" {
+		return trunkState{}
+	}
+	fields := strings.Fields(gitOutput(repo, "rev-list", "--left-right", "--count", ref+"...HEAD"))
+	if len(fields) != 2 {
+		return trunkState{}
+	}
+	behind, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return trunkState{}
+	}
+	ahead, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return trunkState{}
+	}
+	t := trunkState{name: name, ahead: ahead, behind: behind}
+	t.merged = ahead == 0 && behind > 0 && (!onFirstParents(repo, ref) || upstreamGone(repo, branch))
+	return t
+}
+
+// upstreamGone reports whether the upstream branch was deleted from its
+// remote, as the branches command lists it. A branch fast-forwarded into
+// the trunk leaves no merge commit behind, so the upstream deleted after
+// the merge is what tells it from a branch with no commits of its own.
+func upstreamGone(repo, branch string) bool {
+	return firstCommandLine(repo, "git", "for-each-ref", "--format=%(upstream:track)", "refs/heads/"+branch) == "[gone]"
+}
+
+// onFirstParents reports whether HEAD, an ancestor of ref, lies on the first
+// parent line of ref: the commits made on the trunk itself. A branch just
+// created from the trunk lies on it; a branch whose own commits were merged
+// with a merge commit does not, its tip being the second parent of the merge.
+func onFirstParents(repo, ref string) bool {
+	out := gitOutput(repo, "rev-list", "--first-parent", ref, "^HEAD")
+	if out == "" {
+		return true
+	}
+	lines := strings.Split(out, "\n")
+	oldest := lines[len(lines)-1]
+	return gitOutput(repo, "rev-parse", oldest+"^1") == gitOutput(repo, "rev-parse", "HEAD")
+}
+
+// collectBranches reads the local branches of the repository at repo, its
+// default branch picked as defaultBranch does with names. A branch is stale
+// when its last commit is more than staleDays before now.
+func collectBranches(repo string, names []string, staleDays int, now time.Time) branchReport {
+	r := branchReport{repo: repo, defaultBranch: defaultBranch(repo, names)}
+	current := getGitBranch(repo)
+	skip := func(name string) bool {
+		return name == r.defaultBranch || name == current
+	}
+
+	merged := make(map[string]bool)
+	if r.defaultBranch != "" {
+		cmd := exec.Command("git", "branch", "--format=%(refname:short)", "--merged", r.defaultBranch)
+		cmd.Dir = repo
+		if out, err := cmd.Output(); err == nil {
+			for _, name := range strings.Fields(string(out)) {
+				if !skip(name) {
+					merged[name] = true
+					r.merged = append(r.merged, name)
+				}
+			}
+		}
+	}
+
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname:short)\t%(upstream:track)\t%(committerdate:unix)", "refs/heads")
+	cmd.Dir = repo
+	out, err := cmd.Output()
+	if err != nil {
+		return r
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 || skip(fields[0]) || merged[fields[0]] {
+			continue
+		}
+		name := fields[0]
+		if fields[1] == "[gone]" {
+			r.gone = append(r.gone, name)
+			continue
+		}
+		unix, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		if days := int(now.Sub(time.Unix(unix, 0)).Hours() / 24); days > staleDays {
+			r.stale = append(r.stale, staleBranch{name: name, days: days})
+		}
+	}
+	return r
+}
+
+// renderBranches lists the dead branches of each repository that has any.
+func renderBranches(w io.Writer, reports []branchReport, staleDays int, styled bool) {
+	var rows [][]string
+	for _, r := range reports {
+		if r.empty() {
+			continue
+		}
+		var stale []string
+		for _, b := range r.stale {
+			stale = append(stale, fmt.Sprintf("%s (%d days)", b.name, b.days))
+		}
+		rows = append(rows, []string{
+			relPath(r.repo),
+			r.defaultBranch,
+			colorLines(strings.Join(r.merged, "\n"), components.ColorGreen, styled),
+			colorLines(strings.Join(r.gone, "\n"), components.ColorAmber, styled),
+			colorLines(strings.Join(stale, "\n"), components.ColorSeparator, styled),
+		})
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "No merged, gone or stale branches.")
+		return
+	}
+	writeSimpleTable(w, []string{"Path", "Default", "Merged", "Upstream gone", fmt.Sprintf("Stale (>%d days)", staleDays)}, rows, styled)
+}
+
+// pruneBranches deletes the merged branches of the reports once the answer
+// read from r confirms it. The branches are deleted with git branch -d, so
+// git still refuses one that is not merged into the branch checked out.
+func pruneBranches(w io.Writer, r io.Reader, reports []branchReport, styled bool) {
+	count := 0
+	for _, report := range reports {
+		count += len(report.merged)
+	}
+	if count == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Delete %d merged branches? [y/N] ", count)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		fmt.Fprintln(w, "Nothing deleted.")
+		return
+	}
+
+	var rows [][]string
+	for _, report := range reports {
+		if len(report.merged) == 0 {
+			continue
+		}
+		s := &status{styled: styled}
+		for _, name := range report.merged {
+			if s.run(report.repo, false, "git", "branch", "--quiet", "-d", name) == nil {
+				s.add(components.ColorGreen, "Deleted %s", name)
+			}
+		}
+		rows = append(rows, []string{relPath(report.repo), s.String()})
+	}
+	writeSimpleTable(w, []string{"Path", "Prune status"}, rows, styled)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// branchRepo sets up a clone holding a merged branch, an unmerged one, and one
+// whose upstream was deleted.
+func branchRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	repo := filepath.Join(root, "service")
+	runGit(t, root, "init", "--bare", "--initial-branch=main", remote)
+	runGit(t, root, "clone", remote, repo)
+	runGit(t, repo, "config", "user.name", "Test User")
+	runGit(t, repo, "config", "user.email", "test@example.com")
+	runGit(t, repo, "checkout", "-b", "main")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, repo, "push", "-u", "origin", "main")
+
+	runGit(t, repo, "branch", "merged")
+	runGit(t, repo, "checkout", "-b", "wip")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "wip")
+	runGit(t, repo, "checkout", "-b", "review")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "review")
+	runGit(t, repo, "push", "-u", "origin", "review")
+	runGit(t, repo, "push", "origin", "--delete", "review")
+	runGit(t, repo, "checkout", "main")
+	return repo
+}
+
+func TestCollectBranches(t *testing.T) {
+	repo := branchRepo(t)
+
+	got := collectBranches(repo, nil, defaultStaleDays, time.Now())
+	if got.defaultBranch != "main" {
+		t.Fatalf("defaultBranch = %q, want main", got.defaultBranch)
+	}
+	if !reflect.DeepEqual(got.merged, []string{"merged"}) {
+		t.Fatalf("merged = %v, want [merged]", got.merged)
+	}
+	if !reflect.DeepEqual(got.gone, []string{"review"}) {
+		t.Fatalf("gone = %v, want [review]", got.gone)
+	}
+	if len(got.stale) != 0 {
+		t.Fatalf("stale = %v, want none", got.stale)
+	}
+
+	// A year on, the unmerged branch has gone stale.
+	got = collectBranches(repo, nil, defaultStaleDays, time.Now().AddDate(1, 0, 0))
+	if len(got.stale) != 1 || got.stale[0].name != "wip" || got.stale[0].days < 365 {
+		t.Fatalf("stale a year on = %v, want wip", got.stale)
+	}
+
+	var output bytes.Buffer
+	renderBranches(&output, []branchReport{got}, defaultStaleDays, false)
+	want := "| Path | Default | Merged | Upstream gone | Stale (>90 days) |\n"
+	if out := output.String(); !strings.HasPrefix(out, want) || !strings.Contains(out, "| main | merged | review | wip (") {
+		t.Fatalf("renderBranches() =\n%s", out)
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	repo := branchRepo(t)
+
+	if got := defaultBranch(repo, nil); got != "main" {
+		t.Fatalf("defaultBranch() = %q, want main", got)
+	}
+	if got := defaultBranch(repo, []string{"develop", "wip"}); got != "wip" {
+		t.Fatalf("defaultBranch() with names = %q, want the first one the repository has, wip", got)
+	}
+	if got := defaultBranch(repo, []string{"develop"}); got != "main" {
+		t.Fatalf("defaultBranch() with a missing name = %q, want main", got)
+	}
+
+	runGit(t, repo, "checkout", "-b", "feature")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "feature")
+	runGit(t, repo, "checkout", "main")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "one")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "two")
+	runGit(t, repo, "checkout", "feature")
+	if got, want := readTrunk(repo, nil), (trunkState{name: "main", ahead: 1}); got != want {
+		t.Fatalf("readTrunk() before main is pushed = %+v, want %+v against origin/main", got, want)
+	}
+	runGit(t, repo, "push", "origin", "main")
+	if got, want := readTrunk(repo, nil), (trunkState{name: "main", ahead: 1, behind: 2}); got != want {
+		t.Fatalf("readTrunk() = %+v, want %+v", got, want)
+	}
+	runGit(t, repo, "checkout", "merged")
+	if got, want := readTrunk(repo, nil), (trunkState{name: "main", behind: 2}); got != want {
+		t.Fatalf("readTrunk() of a branch without commits = %+v, want %+v", got, want)
+	}
+
+	runGit(t, repo, "checkout", "main")
+	runGit(t, repo, "merge", "--no-ff", "-m", "merge feature", "feature")
+	runGit(t, repo, "push", "origin", "main")
+	if got, want := readTrunk(repo, nil), (trunkState{name: "main"}); got != want {
+		t.Fatalf("readTrunk() on the trunk = %+v, want %+v", got, want)
+	}
+	runGit(t, repo, "checkout", "feature")
+	if got, want := readTrunk(repo, nil), (trunkState{name: "main", behind: 3, merged: true}); got != want {
+		t.Fatalf("readTrunk() of a merged branch = %+v, want %+v", got, want)
+	}
+
+	// A fast-forwarded branch shows as merged once its upstream is gone.
+	runGit(t, repo, "checkout", "-b", "rebased", "main")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "rebased")
+	runGit(t, repo, "push", "-u", "origin", "rebased")
+	runGit(t, repo, "checkout", "main")
+	runGit(t, repo, "merge", "--ff-only", "rebased")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "after")
+	runGit(t, repo, "push", "origin", "main")
+	runGit(t, repo, "checkout", "rebased")
+	if got, want := readTrunk(repo, nil), (trunkState{name: "main", behind: 1}); got != want {
+		t.Fatalf("readTrunk() of a fast-forwarded branch = %+v, want %+v", got, want)
+	}
+	runGit(t, repo, "push", "origin", "--delete", "rebased")
+	runGit(t, repo, "fetch", "--prune")
+	if got, want := readTrunk(repo, nil), (trunkState{name: "main", behind: 1, merged: true}); got != want {
+		t.Fatalf("readTrunk() of a fast-forwarded branch with its upstream gone = %+v, want %+v", got, want)
+	}
+
+	runGit(t, repo, "checkout", "--detach")
+	if got := readTrunk(repo, nil); got != (trunkState{}) {
+		t.Fatalf("readTrunk() with a detached HEAD = %+v, want none", got)
+	}
+}
+
+func TestPruneBranchesAsksFirst(t *testing.T) {
+	repo := branchRepo(t)
+	reports := []branchReport{collectBranches(repo, nil, defaultStaleDays, time.Now())}
+
+	var output bytes.Buffer
+	pruneBranches(&output, strings.NewReader("n\n"), reports, false)
+	if got := output.String(); !strings.Contains(got, "Delete 1 merged branches? [y/N] Nothing deleted.") {
+		t.Fatalf("pruneBranches() declined =\n%s", got)
+	}
+	if firstCommandLine(repo, "git", "branch", "--list", "merged") == "" {
+		t.Fatal("pruneBranches() deleted a branch without confirmation")
+	}
+
+	output.Reset()
+	pruneBranches(&output, strings.NewReader("y\n"), reports, false)
+	if got := output.String(); !strings.Contains(got, "| Deleted merged |") {
+		t.Fatalf("pruneBranches() confirmed =\n%s", got)
+	}
+	if firstCommandLine(repo, "git", "branch", "--list", "merged") != "" {
+		t.Fatal("pruneBranches() left the merged branch")
+	}
+}
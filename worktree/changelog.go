@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// commandChangelog writes the CHANGELOG.md of every workspace module, or of
+// the one named after it, from its release tags.
+const commandChangelog = "changelog"
+
+const (
+	changelogFile       = "CHANGELOG.md"
+	changelogHeader     = "# Changelog\n"
+	changelogUnreleased = "Unreleased"
+)
+
+// changelogRelease holds the commits of one release: the ones between the
+// previous tag and its own, each as its short hash and subject.
+type changelogRelease struct {
+	version string
+	date    string
+	commits []string
+}
+
+// changelogGroups lists the Conventional Commit types a release section is
+// grouped by, in the order they are written.
+var changelogGroups = []struct {
+	kind, title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "Continuous Integration"},
+	{"style", "Style"},
+	{"chore", "Chores"},
+	{"revert", "Reverts"},
+}
+
+// Titles of the groups outside changelogGroups.
+const (
+	changelogBreaking = "Breaking Changes"
+	changelogOther    = "Other Changes"
+)
+
+// conventionalRE matches a Conventional Commit subject: "type(scope)!: text".
+var conventionalRE = regexp.MustCompile(`^([a-z]+)(?:\(([^)]+)\))?(!)?: (.+)$`)
+
+// conventionalCommit is a commit subject read as a Conventional Commit.
+type conventionalCommit struct {
+	kind        string
+	scope       string
+	breaking    bool
+	description string
+}
+
+// parseConventional reads a commit subject as a Conventional Commit. It
+// reports false when the subject does not start with one of the types of
+// changelogGroups, so a "worktree: ..." subject naming a package is not
+// mistaken for one.
+func parseConventional(subject string) (conventionalCommit, bool) {
+	m := conventionalRE.FindStringSubmatch(subject)
+	if m == nil {
+		return conventionalCommit{}, false
+	}
+	for _, group := range changelogGroups {
+		if group.kind == m[1] {
+			return conventionalCommit{kind: m[1], scope: m[2], breaking: m[3] != "", description: m[4]}, true
+		}
+	}
+	return conventionalCommit{}, false
+}
+
+// collectReleases returns the releases of the module in dir, oldest first,
+// walking every semantic version tag it is released with, and the commits
+// since the highest one.
+func collectReleases(dir string) ([]changelogRelease, []string, error) {
+	tags, err := gitTags(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix, versions := moduleTags(dir, tags)
+	parsed := ParseVersions(versions)
+	SortVersions(parsed)
+
+	// The nested modules are read once; their commits are left to their own
+	// changelogs.
+	spec := modulePathspec(dir)
+	var releases []changelogRelease
+	previous := ""
+	for _, v := range parsed {
+		tag := prefix + v.String()
+		releases = append(releases, changelogRelease{
+			version: v.String(),
+			date:    tagDate(dir, tag),
+			commits: commitMessagesBetween(dir, spec, previous, tag),
+		})
+		previous = tag
+	}
+	if previous == "" {
+		return releases, commitMessagesBetween(dir, spec, "", "HEAD"), nil
+	}
+	return releases, commitMessagesBetween(dir, spec, previous, "HEAD"), nil
+}
+
+// commitMessagesBetween lists the commits to the module in dir reachable
+// from to but not from, each as its short hash and subject, within the
+// pathspec spec of the module. An empty from lists every commit up to to.
+// The format is explicit, so log.decorate and format.pretty settings of the
+// user do not change the subjects.
+func commitMessagesBetween(dir string, spec []string, from, to string) []string {
+	revisions := to
+	if from != "" {
+		revisions = from + ".." + to
+	}
+	cmd := exec.Command("git", append([]string{"log", "--no-decorate", "--format=%h %s", revisions}, spec...)...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			msgs = append(msgs, line)
+		}
+	}
+	return msgs
+}
+
+// tagDate returns the date of the commit a tag points at, as YYYY-MM-DD.
+func tagDate(dir, tag string) string {
+	cmd := exec.Command("git", "log", "-1", "--format=%as", tag)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// changelogSection writes the section of one release. When any of its
+// commits is a Conventional Commit, they are grouped by type, breaking
+// changes first and the other commits last; otherwise they are listed as
+// they are.
+func changelogSection(version, date string, commits []string) string {
+	var b strings.Builder
+	b.WriteString("## " + version)
+	if date != "" {
+		b.WriteString(" - " + date)
+	}
+	b.WriteString("\n\n")
+	if len(commits) == 0 {
+		b.WriteString("No changes to this module.\n")
+		return b.String()
+	}
+
+	groups := make(map[string][]string)
+	conventional := false
+	for _, commit := range commits {
+		hash, subject, _ := strings.Cut(commit, " ")
+		c, ok := parseConventional(subject)
+		switch {
+		case !ok:
+			groups[changelogOther] = append(groups[changelogOther], "- "+subject+" ("+hash+")")
+			continue
+		case c.breaking:
+			groups[changelogBreaking] = append(groups[changelogBreaking], changelogItem(c, hash))
+		default:
+			groups[c.kind] = append(groups[c.kind], changelogItem(c, hash))
+		}
+		conventional = true
+	}
+	if !conventional {
+		b.WriteString(strings.Join(groups[changelogOther], "\n") + "\n")
+		return b.String()
+	}
+
+	order := []struct{ key, title string }{{changelogBreaking, changelogBreaking}}
+	for _, group := range changelogGroups {
+		order = append(order, struct{ key, title string }{group.kind, group.title})
+	}
+	order = append(order, struct{ key, title string }{changelogOther, changelogOther})
+
+	var written []string
+	for _, group := range order {
+		if items := groups[group.key]; len(items) > 0 {
+			written = append(written, "### "+group.title+"\n\n"+strings.Join(items, "\n")+"\n")
+		}
+	}
+	b.WriteString(strings.Join(written, "\n"))
+	return b.String()
+}
+
+// changelogItem formats a Conventional Commit as a list item, its scope in
+// bold.
+func changelogItem(c conventionalCommit, hash string) string {
+	if c.scope != "" {
+		return "- **" + c.scope + ":** " + c.description + " (" + hash + ")"
+	}
+	return "- " + c.description + " (" + hash + ")"
+}
+
+// splitChangelog cuts a changelog into the text before its first section and
+// its "## " sections, keyed by the version their heading starts with.
+func splitChangelog(text string) (string, []string, map[string]string) {
+	var preamble strings.Builder
+	var order []string
+	sections := make(map[string]string)
+	key := ""
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if heading, ok := strings.CutPrefix(line, "## "); ok {
+			fields := strings.Fields(heading)
+			key = ""
+			if len(fields) > 0 {
+				key = strings.Trim(fields[0], "[]")
+			}
+			order = append(order, key)
+		}
+		if len(order) == 0 {
+			preamble.WriteString(line)
+			continue
+		}
+		sections[key] += line
+	}
+	return preamble.String(), order, sections
+}
+
+// renderChangelog returns the changelog of the releases, newest first, with
+// an Unreleased section for the commits after the last. A section already in
+// existing is kept as it is, so entries edited by hand survive; only the
+// Unreleased section is written anew, and the sections of versions no longer
+// tagged stay at the end.
+func renderChangelog(existing string, releases []changelogRelease, unreleased []string) string {
+	preamble, order, sections := splitChangelog(existing)
+	if strings.TrimSpace(preamble) == "" {
+		preamble = changelogHeader
+	}
+
+	parts := []string{strings.TrimRight(preamble, "\n")}
+	if len(unreleased) > 0 {
+		parts = append(parts, changelogSection(changelogUnreleased, "", unreleased))
+	}
+	used := map[string]bool{changelogUnreleased: true}
+	for i := len(releases) - 1; i >= 0; i-- {
+		r := releases[i]
+		used[r.version] = true
+		if section, ok := sections[r.version]; ok {
+			parts = append(parts, section)
+			continue
+		}
+		parts = append(parts, changelogSection(r.version, r.date, r.commits))
+	}
+	for _, key := range order {
+		if !used[key] {
+			used[key] = true
+			parts = append(parts, sections[key])
+		}
+	}
+
+	for i, part := range parts {
+		parts[i] = strings.TrimRight(part, "\n")
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// writeChangelog writes or updates the CHANGELOG.md of the module in dir and
+// returns a status line for it.
+func writeChangelog(dir string) (string, error) {
+	releases, unreleased, err := collectReleases(dir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, changelogFile)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	text := renderChangelog(string(existing), releases, unreleased)
+
+	status := fmt.Sprintf("%d releases, %d unreleased commits", len(releases), len(unreleased))
+	switch {
+	case text == string(existing):
+		return "up to date, " + status, nil
+	case existing == nil:
+		status = "created, " + status
+	default:
+		status = "updated, " + status
+	}
+	return status, os.WriteFile(path, []byte(text), 0o644)
+}
+
+// renderChangelogs writes the CHANGELOG.md of each module and reports them in
+// a table.
+func renderChangelogs(w io.Writer, mods []string, modPaths map[string]string, styled bool) {
+	var rows [][]string
+	for _, mod := range mods {
+		dir := modPaths[mod]
+		status, err := writeChangelog(dir)
+		color := components.ColorGreen
+		switch {
+		case err != nil:
+			status, color = err.Error(), components.ColorRed
+		case strings.HasPrefix(status, "up to date"):
+			color = components.ColorBorder
+		}
+		rows = append(rows, []string{
+			relPath(dir),
+			components.ShortPath(mod),
+			colorLines(status, color, styled),
+		})
+	}
+	writeSimpleTable(w, []string{"Path", "Module", "Changelog"}, rows, styled)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConventional(t *testing.T) {
+	tests := map[string]conventionalCommit{
+		"feat: add the changelog":         {kind: "feat", description: "add the changelog"},
+		"fix(render): wrap long cells":    {kind: "fix", scope: "render", description: "wrap long cells"},
+		"refactor!: drop the old options": {kind: "refactor", breaking: true, description: "drop the old options"},
+	}
+	for subject, want := range tests {
+		if got, ok := parseConventional(subject); !ok || got != want {
+			t.Errorf("parseConventional(%q) = %#v, %v, want %#v", subject, got, ok, want)
+		}
+	}
+	for _, subject := range []string{"worktree: add the changelog", "Fix the build", "feat add", "feat(): empty scope"} {
+		if got, ok := parseConventional(subject); ok {
+			t.Errorf("parseConventional(%q) = %#v, want it not conventional", subject, got)
+		}
+	}
+}
+
+func TestChangelogSection(t *testing.T) {
+	got := changelogSection("v0.2.0", "2026-06-01", []string{
+		"a1b2c3d docs: describe the flags",
+		"b2c3d4e feat(render): add the svg output",
+		"c3d4e5f merge the release branch",
+		"d4e5f6a fix!: reject an empty config",
+		"e5f6a7b fix: keep the sort order",
+	})
+	want := `## v0.2.0 - 2026-06-01
+
+### Breaking Changes
+
+- reject an empty config (d4e5f6a)
+
+### Features
+
+- **render:** add the svg output (b2c3d4e)
+
+### Bug Fixes
+
+- keep the sort order (e5f6a7b)
+
+### Documentation
+
+- describe the flags (a1b2c3d)
+
+### Other Changes
+
+- merge the release branch (c3d4e5f)
+`
+	if got != want {
+		t.Fatalf("changelogSection() =\n%s\nwant:\n%s", got, want)
+	}
+
+	// Without any Conventional Commit the commits are listed as they are.
+	got = changelogSection("v0.1.0", "", []string{"a1b2c3d worktree: add the table", "b2c3d4e Initial commit"})
+	want = "## v0.1.0\n\n- worktree: add the table (a1b2c3d)\n- Initial commit (b2c3d4e)\n"
+	if got != want {
+		t.Fatalf("changelogSection(plain) =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// changelogRepo writes a git repository holding a module with two releases,
+// a commit to another module after the first, and an unreleased commit.
+func changelogRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.26\n")
+	commit := func(message string, files ...string) {
+		for _, file := range files {
+			writeTestFile(t, filepath.Join(root, file), message+"\n")
+		}
+		runGit(t, root, "add", "-A")
+		runGit(t, root, "-c", "user.email=test@example.com", "-c", "user.name=test", "commit", "--quiet", "-m", message)
+	}
+	runGit(t, root, "init", "--quiet")
+	commit("feat: first version", "main.go")
+	runGit(t, root, "tag", "v0.1.0")
+	commit("fix: handle no arguments", "main.go")
+	commit("feat(lib): add the lib", filepath.Join("lib", "go.mod"))
+	runGit(t, root, "tag", "lib/v0.1.0")
+	commit("docs: add the readme", "README.md")
+	runGit(t, root, "tag", "v0.2.0")
+	commit("feat: add flags", "main.go")
+	return root
+}
+
+func TestWriteChangelog(t *testing.T) {
+	root := changelogRepo(t)
+	path := filepath.Join(root, changelogFile)
+	// The subjects are read the same whatever the user's log settings.
+	runGit(t, root, "config", "log.decorate", "short")
+	runGit(t, root, "config", "format.pretty", "%H %an")
+
+	status, err := writeChangelog(root)
+	if err != nil || status != "created, 2 releases, 1 unreleased commits" {
+		t.Fatalf("writeChangelog() = %q, %v, want it created", status, err)
+	}
+	got := readTestFile(t, path)
+	for _, want := range []string{
+		"# Changelog\n\n## Unreleased\n\n### Features\n\n- add flags (",
+		"## v0.2.0 - ",
+		"### Bug Fixes\n\n- handle no arguments (",
+		"### Documentation\n\n- add the readme (",
+		"## v0.1.0 - ",
+		"### Features\n\n- first version (",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("CHANGELOG.md missing %q:\n%s", want, got)
+		}
+	}
+	// The module in lib is tagged and changelogged on its own.
+	if strings.Contains(got, "add the lib") || strings.Contains(got, "lib/v0.1.0") {
+		t.Fatalf("CHANGELOG.md lists the lib module:\n%s", got)
+	}
+	if status, _ := writeChangelog(root); !strings.HasPrefix(status, "up to date") {
+		t.Fatalf("writeChangelog() again = %q, want it up to date", status)
+	}
+
+	// An edited section is kept, the unreleased commit moves to the new
+	// release, and a hand-written section for an untagged version stays.
+	edited := strings.Replace(got, "- handle no arguments", "- handle running without arguments", 1) +
+		"\n## v0.0.1 - 2020-01-01\n\nThe prototype.\n"
+	writeTestFile(t, path, edited)
+	runGit(t, root, "tag", "v0.3.0")
+
+	status, err = writeChangelog(root)
+	if err != nil || status != "updated, 3 releases, 0 unreleased commits" {
+		t.Fatalf("writeChangelog() after a release = %q, %v, want it updated", status, err)
+	}
+	got = readTestFile(t, path)
+	for _, want := range []string{
+		"# Changelog\n\n## v0.3.0 - ",
+		"- handle running without arguments (",
+		"## v0.1.0 - ",
+		"## v0.0.1 - 2020-01-01\n\nThe prototype.\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("CHANGELOG.md missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Unreleased") {
+		t.Fatalf("CHANGELOG.md kept the unreleased section:\n%s", got)
+	}
+	if strings.Index(got, "## v0.3.0") > strings.Index(got, "## v0.2.0") || strings.Index(got, "## v0.1.0") > strings.Index(got, "## v0.0.1") {
+		t.Fatalf("CHANGELOG.md sections out of order:\n%s", got)
+	}
+	if strings.Contains(got, "tag: ") {
+		t.Fatalf("CHANGELOG.md holds decorations:\n%s", got)
+	}
+
+	var output strings.Builder
+	chdir(t, filepath.Dir(root))
+	renderChangelogs(&output, []string{"example.com/app"}, map[string]string{"example.com/app": filepath.Base(root)}, false)
+	if want := "| ./" + filepath.Base(root) + " | example.com/app | up to date"; !strings.Contains(output.String(), want) {
+		t.Fatalf("renderChangelogs() =\n%s\nwant it to hold %q", output.String(), want)
+	}
+}
+
+func TestParseOptionsChangelog(t *testing.T) {
+	originalArgs := os.Args
+	originalFlags := flag.CommandLine
+	defer func() {
+		os.Args = originalArgs
+		flag.CommandLine = originalFlags
+	}()
+	os.Args = []string{"worktree", "changelog", "gofsck"}
+	flag.CommandLine = flag.NewFlagSet("worktree", flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseOptions()
+	if !opts.Changelog || opts.FilterArg != "gofsck" || opts.FilterPath == "" {
+		t.Fatalf("ParseOptions() = %#v, want the changelog of gofsck", opts)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/titpetric/tools/worktree/components"
+	"github.com/titpetric/tools/worktree/config"
+)
+
+// The settings of --color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// colorModes lists the values --color takes.
+var colorModes = []string{colorAuto, colorAlways, colorNever}
+
+// forceColor makes supportsANSI style output that is not a terminal, as
+// --color=always and FORCE_COLOR ask.
+var forceColor bool
+
+// resolveColor returns whether output is colored always, never, or only on a
+// terminal. A --color other than auto wins; then FORCE_COLOR or
+// CLICOLOR_FORCE set to anything but 0 color always, and NO_COLOR set to
+// anything or CLICOLOR=0 turn color off.
+func resolveColor(flag string, getenv func(string) string) string {
+	if flag != "" && flag != colorAuto {
+		return flag
+	}
+	forced := func(name string) bool {
+		value := getenv(name)
+		return value != "" && value != "0"
+	}
+	switch {
+	case forced("FORCE_COLOR"), forced("CLICOLOR_FORCE"):
+		return colorAlways
+	case getenv("NO_COLOR") != "", getenv("CLICOLOR") == "0":
+		return colorNever
+	}
+	return colorAuto
+}
+
+// basicTerminals are the TERM values of terminals without 256 colors, which
+// fall back from the dark theme to 16-color.
+var basicTerminals = []string{"linux", "ansi", "vt100", "vt220", "cons25"}
+
+// themePalette returns the palette of the configured theme with the colors
+// of the palette section over it.
+func themePalette(cfg *config.Config, term string) (components.Palette, error) {
+	name := cfg.Display.Theme
+	if name == "" {
+		name = config.Themes[0]
+	}
+	if name == config.Themes[0] && slices.Contains(basicTerminals, term) {
+		name = "16-color"
+	}
+	palette, ok := components.Theme(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q, want one of %s", name, strings.Join(config.Themes, ", "))
+	}
+	for color, value := range cfg.Palette {
+		code, err := components.ParseColor(value)
+		if err != nil {
+			return nil, fmt.Errorf("palette: %s: %w", color, err)
+		}
+		palette[color] = code
+	}
+	return palette, nil
+}
+
+// applyColor sets the colors of the output from --color, the environment
+// and the configured theme. With color off the tables keep their layout,
+// only without escape sequences.
+func applyColor(opts *Options, cfg *config.Config) error {
+	palette, err := themePalette(cfg, os.Getenv("TERM"))
+	if err != nil {
+		return err
+	}
+	if err := components.SetPalette(palette); err != nil {
+		return fmt.Errorf("palette: %w", err)
+	}
+	switch resolveColor(opts.Color, os.Getenv) {
+	case colorAlways:
+		forceColor = true
+	case colorNever:
+		components.DisableColor()
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/titpetric/tools/worktree/components"
+	"github.com/titpetric/tools/worktree/config"
+)
+
+func TestResolveColor(t *testing.T) {
+	tests := []struct {
+		flag string
+		env  map[string]string
+		want string
+	}{
+		{"auto", nil, colorAuto},
+		{"never", map[string]string{"FORCE_COLOR": "1"}, colorNever},
+		{"always", map[string]string{"NO_COLOR": "1"}, colorAlways},
+		{"auto", map[string]string{"NO_COLOR": "1"}, colorNever},
+		{"auto", map[string]string{"CLICOLOR": "0"}, colorNever},
+		{"auto", map[string]string{"CLICOLOR": "1"}, colorAuto},
+		{"auto", map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, colorAlways},
+		{"auto", map[string]string{"FORCE_COLOR": "0"}, colorAuto},
+		{"auto", map[string]string{"CLICOLOR_FORCE": "1", "CLICOLOR": "0"}, colorAlways},
+	}
+	for _, tt := range tests {
+		getenv := func(name string) string { return tt.env[name] }
+		if got := resolveColor(tt.flag, getenv); got != tt.want {
+			t.Errorf("resolveColor(%s, %v) = %s, want %s", tt.flag, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestThemePalette(t *testing.T) {
+	cfg := &config.Config{Display: config.Display{Theme: "light"}, Palette: map[string]string{"green": "#008700", "red": "124"}}
+	palette, err := themePalette(cfg, "xterm-256color")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"white": "\033[38;5;236m",
+		"green": "\033[38;2;0;135;0m",
+		"red":   "\033[38;5;124m",
+	}
+	for name, code := range want {
+		if palette[name] != code {
+			t.Errorf("themePalette(light)[%s] = %q, want %q", name, palette[name], code)
+		}
+	}
+
+	// The default dark theme falls back to 16 colors on a basic terminal.
+	palette, err = themePalette(&config.Config{}, "linux")
+	if err != nil || palette["green"] != "\033[32m" {
+		t.Fatalf("themePalette(linux)[green] = %q, %v, want the 16-color green", palette["green"], err)
+	}
+
+	for _, cfg := range []*config.Config{
+		{Display: config.Display{Theme: "solarized"}},
+		{Palette: map[string]string{"green": "256"}},
+		{Palette: map[string]string{"green": "#0087"}},
+	} {
+		if _, err := themePalette(cfg, "xterm"); err == nil {
+			t.Errorf("themePalette(%+v) succeeded", cfg)
+		}
+	}
+}
+
+func TestApplyColor(t *testing.T) {
+	t.Cleanup(func() {
+		forceColor = false
+		dark, _ := components.Theme("dark")
+		components.SetPalette(dark)
+	})
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	if err := applyColor(&Options{Color: colorAlways}, &config.Config{Display: config.Display{Theme: "high-contrast"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !forceColor || components.ColorGreen != "\033[38;5;46m" || components.ColorReset != "\033[0m" {
+		t.Fatalf("applyColor(always, high-contrast) = %v, %q, want forced high-contrast colors", forceColor, components.ColorGreen)
+	}
+
+	forceColor = false
+	if err := applyColor(&Options{Color: colorNever}, &config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if forceColor || components.ColorGreen != "" || components.ColorReset != "" {
+		t.Fatalf("applyColor(never) left colors set: %q", components.ColorGreen)
+	}
+	var out strings.Builder
+	renderDependencyMatrix(&out, []moduleInfo{{Name: "example.com/app", Uses: []string{"example.com/lib"}}, {Name: "example.com/lib"}}, nil, nil, false, true)
+	if got := out.String(); strings.Contains(got, "\033") || !strings.Contains(got, "╭") {
+		t.Fatalf("renderDependencyMatrix() without color = %q, want the table without escape sequences", got)
+	}
+
+	if err := applyColor(&Options{}, &config.Config{Palette: map[string]string{"purple": "93"}}); err == nil {
+		t.Fatal("applyColor() with an unknown palette color succeeded")
+	}
+}
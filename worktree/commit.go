@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// commitLine formats a requirement change as a line of a commit message.
+func commitLine(c depChange) string {
+	switch {
+	case c.to == "":
+		return "drop " + c.path + " " + c.from
+	case c.from == "":
+		return "add " + c.path + " " + c.to
+	default:
+		return "bump " + c.path + " " + c.from + " → " + c.to
+	}
+}
+
+// updateLines returns the commit message lines of one module update, the go
+// and toolchain directive changes first.
+func updateLines(u moduleUpdate) []string {
+	var lines []string
+	if u.goTo != "" {
+		lines = append(lines, goVersionChange(u.goFrom, u.goTo))
+	}
+	if u.toolchainFrom != u.toolchainTo {
+		lines = append(lines, toolchainChange(u.toolchainFrom, u.toolchainTo))
+	}
+	for _, change := range u.changes {
+		lines = append(lines, commitLine(change))
+	}
+	return lines
+}
+
+// commitMessage writes the commit message for the updates of the modules in
+// one repository. A single change makes up the whole subject; anything more
+// is summarized in the subject and listed in the body, grouped by module when
+// the repository holds several.
+func commitMessage(updates []moduleUpdate) string {
+	var total []string
+	for _, u := range updates {
+		total = append(total, updateLines(u)...)
+	}
+	if len(total) == 1 {
+		return "deps: " + total[0]
+	}
+
+	if len(updates) == 1 {
+		var b strings.Builder
+		fmt.Fprintf(&b, "deps: update %s\n", components.ShortPath(updates[0].modPath))
+		b.WriteString("\n")
+		for _, line := range total {
+			b.WriteString("- " + line + "\n")
+		}
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "deps: update %d modules\n", len(updates))
+	for _, u := range updates {
+		b.WriteString("\n" + components.ShortPath(u.modPath) + ":\n")
+		for _, line := range updateLines(u) {
+			b.WriteString("- " + line + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// commitUpdates commits the go.mod and go.sum changes of the updated modules,
+// and the go.work and go.work.sum changes of updated workspaces, one commit
+// per git repository, and reports each commit in a table. When branch is set,
+// the commit is made on a new branch of that name, so a workspace wide bump
+// becomes one reviewable branch per repository. Only the files of the updates
+// are committed; anything else staged or modified in the repository is left
+// as it was.
+func commitUpdates(w io.Writer, updates []moduleUpdate, branch string, styled bool) {
+	repos := make(map[string][]moduleUpdate)
+	var failed [][]string
+	for _, u := range updates {
+		root, err := gitTopLevel(u.dir)
+		if err != nil {
+			failed = append(failed, []string{relPath(u.dir), "", colorLines("not a git repository", components.ColorRed, styled)})
+			continue
+		}
+		repos[root] = append(repos[root], u)
+	}
+
+	paths := make([]string, 0, len(repos))
+	for path := range repos {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var rows [][]string
+	for _, root := range paths {
+		s := &status{styled: styled}
+		commitRepo(s, root, repos[root], branch)
+		rows = append(rows, []string{relPath(root), getGitBranch(root), s.String()})
+	}
+	writeSimpleTable(w, []string{"Path", "Branch", "Commit status"}, append(rows, failed...), styled)
+}
+
+// commitRepo commits the updates of the modules in the repository at root,
+// recording the outcome in s.
+func commitRepo(s *status, root string, updates []moduleUpdate, branch string) {
+	var files []string
+	for _, u := range updates {
+		dir, err := filepath.Abs(u.dir)
+		if err != nil {
+			s.failed = true
+			s.add(components.ColorRed, "%v", err)
+			return
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			s.failed = true
+			s.add(components.ColorRed, "%v", err)
+			return
+		}
+		names := []string{"go.mod", "go.sum"}
+		if u.work {
+			names = []string{"go.work", "go.work.sum"}
+		}
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				files = append(files, filepath.ToSlash(filepath.Join(rel, name)))
+			}
+		}
+	}
+
+	if branch != "" {
+		if s.run(root, false, "git", "checkout", "--quiet", "-b", branch) != nil {
+			return
+		}
+	}
+
+	message := commitMessage(updates)
+	if s.run(root, false, append([]string{"git", "add", "--"}, files...)...) != nil {
+		return
+	}
+	if s.run(root, false, append([]string{"git", "commit", "--quiet", "-m", message, "--"}, files...)...) != nil {
+		return
+	}
+
+	subject, _, _ := strings.Cut(message, "\n")
+	hash := firstCommandLine(root, "git", "rev-parse", "--short", "HEAD")
+	s.add(components.ColorGreen, "%s %s", hash, subject)
+}
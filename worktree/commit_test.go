@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates []moduleUpdate
+		want    string
+	}{
+		{
+			name: "single bump",
+			updates: []moduleUpdate{{
+				modPath: "example.com/app",
+				changes: []depChange{{path: "example.com/foo", from: "v1.2.0", to: "v1.3.0"}},
+			}},
+			want: "deps: bump example.com/foo v1.2.0 → v1.3.0",
+		},
+		{
+			name:    "go directive only",
+			updates: []moduleUpdate{{modPath: "example.com/app", goFrom: "1.25", goTo: "1.27"}},
+			want:    "deps: go 1.25 → 1.27",
+		},
+		{
+			name: "one module",
+			updates: []moduleUpdate{{
+				modPath: "github.com/acme/app",
+				goFrom:  "1.25",
+				goTo:    "1.27",
+				changes: []depChange{
+					{path: "example.com/foo", from: "v1.2.0", to: "v1.3.0"},
+					{path: "example.com/new", to: "v0.1.0"},
+					{path: "example.com/old", from: "v0.2.0"},
+				},
+			}},
+			want: "deps: update acme/app\n\n" +
+				"- go 1.25 → 1.27\n" +
+				"- bump example.com/foo v1.2.0 → v1.3.0\n" +
+				"- add example.com/new v0.1.0\n" +
+				"- drop example.com/old v0.2.0",
+		},
+		{
+			name: "several modules",
+			updates: []moduleUpdate{
+				{modPath: "example.com/app", changes: []depChange{{path: "example.com/foo", from: "v1.2.0", to: "v1.3.0"}}},
+				{modPath: "example.com/app/cli", goFrom: "1.25", goTo: "1.27"},
+			},
+			want: "deps: update 2 modules\n\n" +
+				"example.com/app:\n- bump example.com/foo v1.2.0 → v1.3.0\n\n" +
+				"example.com/app/cli:\n- go 1.25 → 1.27",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := commitMessage(test.updates); got != test.want {
+				t.Errorf("commitMessage() =\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+// TestCommitUpdatesOnBranch checks the update is committed on a new branch,
+// and that only the go.mod of the updated module goes into the commit.
+func TestCommitUpdatesOnBranch(t *testing.T) {
+	root := t.TempDir()
+	runGit(t, root, "init", "--quiet", "-b", "main")
+	runGit(t, root, "config", "user.name", "Test User")
+	runGit(t, root, "config", "user.email", "test@example.com")
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.25\n")
+	writeTestFile(t, filepath.Join(root, "README.md"), "# app\n")
+	runGit(t, root, "add", ".")
+	runGit(t, root, "commit", "--quiet", "-m", "initial")
+	chdir(t, root)
+
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.27\n")
+	writeTestFile(t, filepath.Join(root, "README.md"), "# app, edited\n")
+
+	var output bytes.Buffer
+	updates := []moduleUpdate{{dir: ".", modPath: "example.com/app", goFrom: "1.25", goTo: "1.27"}}
+	commitUpdates(&output, updates, "deps/go-1.27", false)
+
+	got := output.String()
+	for _, want := range []string{"| Path | Branch | Commit status |", "| deps/go-1.27 |", "deps: go 1.25 → 1.27 |"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("commitUpdates() output missing %q:\n%s", want, got)
+		}
+	}
+
+	subject, err := exec.Command("git", "log", "-1", "--format=%s").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(subject)), "deps: go 1.25 → 1.27"; got != want {
+		t.Fatalf("commit subject = %q, want %q", got, want)
+	}
+	files, err := exec.Command("git", "show", "--name-only", "--format=", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(files)); got != "go.mod" {
+		t.Fatalf("commit holds %q, want only go.mod", got)
+	}
+}
+
+// TestCommitUpdatesWithGoWork checks a go directive update commits the go.work
+// and go.work.sum of the workspace along with the go.mod of its module.
+func TestCommitUpdatesWithGoWork(t *testing.T) {
+	root := t.TempDir()
+	runGit(t, root, "init", "--quiet", "-b", "main")
+	runGit(t, root, "config", "user.name", "Test User")
+	runGit(t, root, "config", "user.email", "test@example.com")
+	writeTestFile(t, filepath.Join(root, "go.work"), "go 1.25\n\nuse ./app\n")
+	writeTestFile(t, filepath.Join(root, "go.work.sum"), "")
+	writeTestFile(t, filepath.Join(root, "app", "go.mod"), "module example.com/app\n\ngo 1.25\n")
+	runGit(t, root, "add", ".")
+	runGit(t, root, "commit", "--quiet", "-m", "initial")
+	chdir(t, root)
+
+	writeTestFile(t, filepath.Join(root, "go.work"), "go 1.27\n\nuse ./app\n")
+	writeTestFile(t, filepath.Join(root, "go.work.sum"), "example.com/dep v1.0.0 h1:abc=\n")
+	writeTestFile(t, filepath.Join(root, "app", "go.mod"), "module example.com/app\n\ngo 1.27\n")
+
+	var output bytes.Buffer
+	updates := []moduleUpdate{
+		{dir: ".", modPath: "./go.work", work: true, goFrom: "1.25", goTo: "1.27"},
+		{dir: "app", modPath: "example.com/app", goFrom: "1.25", goTo: "1.27"},
+	}
+	commitUpdates(&output, updates, "deps/go-1.27", false)
+
+	files, err := exec.Command("git", "show", "--name-only", "--format=", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Fields(string(files)), []string{"app/go.mod", "go.work", "go.work.sum"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("commit holds %v, want %v\n%s", got, want, output.String())
+	}
+	if status := firstCommandLine(root, "git", "status", "--porcelain"); status != "" {
+		t.Fatalf("git status after the commit = %q, want a clean tree", status)
+	}
+}
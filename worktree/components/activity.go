@@ -0,0 +1,43 @@
+package components
+
+import (
+	"strconv"
+	"time"
+)
+
+// dateLayout is how the activity columns write a date.
+const dateLayout = "2006-01-02"
+
+// Date formats the date of a tag or a commit, nothing when it is unknown.
+func Date(t time.Time) Cell {
+	if t.IsZero() {
+		return nil
+	}
+	return Cell{ColorTeal + t.Format(dateLayout) + ColorReset}
+}
+
+// Age formats the days since a release. A release older than the age at
+// which unreleased work is flagged is coloured amber when stale is set.
+func Age(days int, known, stale bool) Cell {
+	if !known {
+		return nil
+	}
+	color := ColorTeal
+	if stale {
+		color = ColorAmber
+	}
+	unit := " days"
+	if days == 1 {
+		unit = " day"
+	}
+	return Cell{color + strconv.Itoa(days) + unit + ColorReset}
+}
+
+// Contributors formats the number of authors since a release, nothing for
+// none.
+func Contributors(n int) Cell {
+	if n == 0 {
+		return nil
+	}
+	return Cell{ColorWhite + strconv.Itoa(n) + ColorReset}
+}
@@ -4,4 +4,8 @@ package components
 type Dependent struct {
 	Name     string
 	Outdated bool
+
+	// Retracted is the version the dependent requires when the dependency
+	// retracts it, empty otherwise.
+	Retracted string
 }
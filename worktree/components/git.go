@@ -18,28 +18,83 @@ type Git struct {
 	LatestTag      string
 	Ahead          int
 	Unpushed       int
+	Behind         int
+	DefaultBranch  string
+	TrunkAhead     int
+	TrunkBehind    int
+	TrunkMerged    bool
 	Msgs           []string
 	DiffLines      []string
 	UntrackedFiles []string
 	Issues         []Issue
 }
 
-// Branch formats the git branch with optional commits-ahead indicator.
+// FarBehind is how many commits a feature branch is behind the default
+// branch before the count is shown red.
+const FarBehind = 50
+
+// Branch formats the git branch with optional commits-ahead indicator. A
+// feature branch also shows how far it is ahead of and behind the default
+// branch, or that it is merged into it.
 func (g Git) Branch() Cell {
 	if g.BranchName == "" {
 		return nil
 	}
+	trunk := g.DefaultBranch
+	if trunk == "" {
+		trunk = "main"
+	}
 	c := ColorTeal
-	if g.BranchName != "main" {
+	if g.BranchName != trunk {
 		c = ColorAmber
 	}
 	line := c + g.BranchName + ColorReset
 	if g.Ahead > 0 {
 		line += fmt.Sprintf(" %s(%s+%d ahead%s)%s", ColorWhite, ColorRed, g.Ahead, ColorWhite, ColorReset)
 	}
+	if g.Diverged() {
+		line += fmt.Sprintf(" %s(%sdiverged ↑%d ↓%d%s)%s", ColorWhite, ColorRed, g.Unpushed, g.Behind, ColorWhite, ColorReset)
+	} else if g.Behind > 0 {
+		line += fmt.Sprintf(" %s(%s-%d behind%s)%s", ColorWhite, ColorYellow, g.Behind, ColorWhite, ColorReset)
+	}
+	if g.Feature() {
+		switch {
+		case g.Merged():
+			line += fmt.Sprintf(" %s(%smerged into %s%s)%s", ColorWhite, ColorGreen, trunk, ColorWhite, ColorReset)
+		case g.TrunkBehind > 0 || g.TrunkAhead == 0:
+			behind := ColorYellow
+			if g.TrunkBehind >= FarBehind {
+				behind = ColorRed
+			}
+			line += fmt.Sprintf(" %s(%d ahead, %s%d behind%s %s)%s", ColorWhite, g.TrunkAhead, behind, g.TrunkBehind, ColorWhite, trunk, ColorReset)
+		default:
+			line += fmt.Sprintf(" %s(%d ahead of %s)%s", ColorWhite, g.TrunkAhead, trunk, ColorReset)
+		}
+	}
 	return Cell{line}
 }
 
+// Feature reports whether a branch other than the default branch is checked
+// out, so TrunkAhead and TrunkBehind compare the two.
+func (g Git) Feature() bool {
+	return g.DefaultBranch != "" && g.BranchName != "" && g.BranchName != "HEAD" && g.BranchName != g.DefaultBranch
+}
+
+// Merged reports whether the commits of a feature branch have been merged
+// into the default branch: with a merge commit, or fast-forwarded or
+// rebased onto it when the upstream branch has since been deleted. A branch
+// without commits of its own is not, nor is a fast-forwarded branch whose
+// upstream is still there, which shows as only behind.
+func (g Git) Merged() bool {
+	return g.Feature() && g.TrunkMerged
+}
+
+// Diverged reports whether the branch and its upstream both hold commits the
+// other does not, so it can no longer be fast-forwarded either way.
+func (g Git) Diverged() bool {
+	return g.Unpushed > 0 && g.Behind > 0
+}
+
 func (g Git) summaryLine() Cell {
 	if g.Unpushed > 0 {
 		return Cell{ColorRed + fmt.Sprintf("Unpushed changes: %d", g.Unpushed) + ColorReset}
@@ -1,9 +1,14 @@
 package components
 
-// Latest formats the latest git tag.
-func Latest(tag string) Cell {
+// Latest formats the latest git tag. A module whose commits since the tag
+// have waited too long for a release has it coloured amber.
+func Latest(tag string, stale bool) Cell {
 	if tag == "" {
 		return nil
 	}
-	return Cell{ColorWhite + tag + ColorReset}
+	color := ColorWhite
+	if stale {
+		color = ColorAmber
+	}
+	return Cell{color + tag + ColorReset}
 }
@@ -0,0 +1,12 @@
+package components
+
+import "strings"
+
+// Retracted formats the versions a module retracts, as its go.mod writes
+// them.
+func Retracted(versions []string) Cell {
+	if len(versions) == 0 {
+		return nil
+	}
+	return Cell{ColorBorder + "retracted " + ColorRed + strings.Join(versions, ColorBorder+", "+ColorRed) + ColorReset}
+}
@@ -1,7 +1,8 @@
 package components
 
-// xterm256 color constants.
-const (
+// The colors of the table, as escape sequences. They hold the dark theme
+// until SetPalette selects another, and are empty when color is off.
+var (
 	ColorReset      = "\033[0m"
 	ColorBorder     = "\033[38;5;60m"
 	ColorSeparator  = "\033[38;5;238m"
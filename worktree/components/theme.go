@@ -0,0 +1,129 @@
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Palette maps color names, as ColorNames lists them, to escape sequences.
+type Palette map[string]string
+
+// colors maps each color name to the variable holding it.
+var colors = map[string]*string{
+	"border":      &ColorBorder,
+	"separator":   &ColorSeparator,
+	"header":      &ColorHeader,
+	"amber":       &ColorAmber,
+	"dark_orange": &ColorDarkOrange,
+	"green":       &ColorGreen,
+	"green_light": &ColorGreenLt,
+	"teal":        &ColorTeal,
+	"white":       &ColorWhite,
+	"yellow":      &ColorYellow,
+	"red":         &ColorRed,
+}
+
+// ColorNames lists the names a palette sets colors by, sorted.
+func ColorNames() []string {
+	names := make([]string, 0, len(colors))
+	for name := range colors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// xterm256 returns the escape sequence of an xterm-256 foreground color.
+func xterm256(n int) string {
+	return fmt.Sprintf("\033[38;5;%dm", n)
+}
+
+// ansi16 returns the escape sequence of a 16-color foreground SGR code.
+func ansi16(code int) string {
+	return fmt.Sprintf("\033[%dm", code)
+}
+
+// themes holds the built-in palettes by name. Dark is the default; light
+// darkens the text colors for a light background, high-contrast brightens
+// them, and 16-color keeps to the colors every terminal has, leaving white
+// text in the terminal's own foreground.
+var themes = map[string]Palette{
+	"dark": {
+		"border": xterm256(60), "separator": xterm256(238), "header": xterm256(146),
+		"amber": xterm256(214), "dark_orange": xterm256(166), "green": xterm256(114),
+		"green_light": xterm256(156), "teal": xterm256(72), "white": xterm256(255),
+		"yellow": xterm256(220), "red": xterm256(167),
+	},
+	"light": {
+		"border": xterm256(60), "separator": xterm256(250), "header": xterm256(61),
+		"amber": xterm256(172), "dark_orange": xterm256(130), "green": xterm256(28),
+		"green_light": xterm256(70), "teal": xterm256(30), "white": xterm256(236),
+		"yellow": xterm256(136), "red": xterm256(160),
+	},
+	"high-contrast": {
+		"border": xterm256(111), "separator": xterm256(245), "header": xterm256(231),
+		"amber": xterm256(214), "dark_orange": xterm256(202), "green": xterm256(46),
+		"green_light": xterm256(121), "teal": xterm256(51), "white": xterm256(231),
+		"yellow": xterm256(226), "red": xterm256(196),
+	},
+	"16-color": {
+		"border": ansi16(34), "separator": ansi16(90), "header": ansi16(36),
+		"amber": ansi16(33), "dark_orange": ansi16(31), "green": ansi16(32),
+		"green_light": ansi16(92), "teal": ansi16(36), "white": ansi16(39),
+		"yellow": ansi16(93), "red": ansi16(91),
+	},
+}
+
+// Theme returns a copy of the built-in palette of the theme named.
+func Theme(name string) (Palette, bool) {
+	theme, ok := themes[name]
+	if !ok {
+		return nil, false
+	}
+	p := make(Palette, len(theme))
+	for color, value := range theme {
+		p[color] = value
+	}
+	return p, true
+}
+
+// ParseColor reads a color of a custom palette: an xterm-256 index such as
+// 214, or an RGB hex color such as #ffaf00 for terminals with true color.
+func ParseColor(value string) (string, error) {
+	if hex, ok := strings.CutPrefix(value, "#"); ok {
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return "", fmt.Errorf("color %q is not #rrggbb", value)
+		}
+		return fmt.Sprintf("\033[38;2;%d;%d;%dm", rgb>>16, rgb>>8&0xff, rgb&0xff), nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > 255 {
+		return "", fmt.Errorf("color %q is not an xterm-256 index 0 to 255 or #rrggbb", value)
+	}
+	return xterm256(n), nil
+}
+
+// SetPalette sets the colors the palette names; the others keep their value.
+func SetPalette(p Palette) error {
+	for name, value := range p {
+		color, ok := colors[name]
+		if !ok {
+			return fmt.Errorf("unknown color %q, want one of %s", name, strings.Join(ColorNames(), ", "))
+		}
+		*color = value
+	}
+	ColorReset = "\033[0m"
+	return nil
+}
+
+// DisableColor empties every color, so styled output keeps its layout
+// without any escape sequences.
+func DisableColor() {
+	for _, color := range colors {
+		*color = ""
+	}
+	ColorReset = ""
+}
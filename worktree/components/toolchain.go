@@ -0,0 +1,14 @@
+package components
+
+// Toolchain formats the toolchain directive of a go.mod. A toolchain below
+// the module's go directive, which the go tool refuses, is coloured red.
+func Toolchain(name string, belowGo bool) Cell {
+	if name == "" {
+		return nil
+	}
+	color := ColorTeal
+	if belowGo {
+		color = ColorRed
+	}
+	return Cell{color + name + ColorReset}
+}
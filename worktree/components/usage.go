@@ -19,9 +19,12 @@ func (u Usage) Compact() Cell {
 		s := fmt.Sprintf("%d", len(u.UsedBy))
 		c := ColorGreen
 		for _, d := range u.UsedBy {
+			if d.Retracted != "" {
+				c = ColorRed
+				break
+			}
 			if d.Outdated {
 				c = ColorYellow
-				break
 			}
 		}
 		parts = append(parts, ColorBorder+"↑ "+c+s+ColorReset)
@@ -45,7 +48,10 @@ func (u Usage) Verbose() Cell {
 		var parts []string
 		for _, d := range u.UsedBy {
 			c := ColorGreen
-			if d.Outdated {
+			switch {
+			case d.Retracted != "":
+				c = ColorRed
+			case d.Outdated:
 				c = ColorYellow
 			}
 			parts = append(parts, c+d.Name+ColorReset)
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The subcommands of "worktree config" that work without the setup screen,
+// so dotfile installers and CI can configure the tool without a terminal.
+const (
+	CommandGet    = "get"
+	CommandSet    = "set"
+	CommandAdd    = "add"
+	CommandRemove = "remove"
+	CommandPath   = "path"
+)
+
+// Field returns the setting with the dotted key, such as "scan.ignore_paths".
+func (c *Config) Field(key string) (Field, error) {
+	var keys []string
+	for _, field := range c.Fields() {
+		if field.Key == key {
+			return field, nil
+		}
+		keys = append(keys, field.Key)
+	}
+	return Field{}, fmt.Errorf("unknown setting %q, want one of %s", key, strings.Join(keys, ", "))
+}
+
+// Values returns the setting as text: "true" or "false" for a boolean, the
+// value of a choice or a number, and one entry per value for a list or an
+// order. An
+// order that names no columns reads as its defaults, as it does on screen.
+func (f Field) Values() []string {
+	v := f.state()
+	switch {
+	case f.IsList():
+		return v.entries()
+	case f.IsChoice():
+		return []string{v.choice}
+	case f.IsOrder():
+		return v.order
+	case f.IsNumber():
+		return []string{strconv.Itoa(v.number)}
+	}
+	return []string{strconv.FormatBool(v.flag)}
+}
+
+// Set replaces the setting with values. A boolean takes one value strconv
+// can parse, a choice one of its choices, a number a whole number of 0 or
+// more, and a list or an order any number of entries, each of which may hold
+// several separated by commas.
+func (f Field) Set(values []string) error {
+	switch {
+	case f.IsList():
+		*f.List = splitEntries(values)
+		return nil
+	case f.IsOrder():
+		entries := splitEntries(values)
+		if err := f.checkChoices(entries); err != nil {
+			return err
+		}
+		*f.Order = []string{}
+		for _, entry := range entries {
+			if !slices.Contains(*f.Order, entry) {
+				*f.Order = append(*f.Order, entry)
+			}
+		}
+		return nil
+	}
+
+	if len(values) != 1 {
+		return fmt.Errorf("%s takes one value, got %d", f.Key, len(values))
+	}
+	if f.IsChoice() {
+		if err := f.checkChoices(values); err != nil {
+			return err
+		}
+		*f.Choice = values[0]
+		return nil
+	}
+	if f.IsNumber() {
+		n, err := strconv.Atoi(values[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s takes a whole number of 0 or more, got %q", f.Key, values[0])
+		}
+		*f.Number = n
+		return nil
+	}
+	flag, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return fmt.Errorf("%s takes true or false, got %q", f.Key, values[0])
+	}
+	*f.Bool = flag
+	return nil
+}
+
+// Add appends the entries of values a list or an order does not hold yet.
+func (f Field) Add(values []string) error {
+	current, err := f.entryList()
+	if err != nil {
+		return err
+	}
+	entries := f.Values()
+	for _, entry := range splitEntries(values) {
+		if !slices.Contains(entries, entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if f.IsOrder() {
+		if err := f.checkChoices(entries); err != nil {
+			return err
+		}
+	}
+	*current = entries
+	return nil
+}
+
+// Remove drops the entries of values from a list or an order. An entry the
+// setting does not hold is not an error.
+func (f Field) Remove(values []string) error {
+	current, err := f.entryList()
+	if err != nil {
+		return err
+	}
+	drop := splitEntries(values)
+	entries := slices.DeleteFunc(f.Values(), func(entry string) bool {
+		return slices.Contains(drop, entry)
+	})
+	if entries == nil {
+		entries = []string{}
+	}
+	*current = entries
+	return nil
+}
+
+// entryList returns the slice a list or an order setting is held in.
+func (f Field) entryList() (*[]string, error) {
+	switch {
+	case f.IsList():
+		return f.List, nil
+	case f.IsOrder():
+		return f.Order, nil
+	}
+	return nil, fmt.Errorf("%s is not a list", f.Key)
+}
+
+// checkChoices reports the first of values that is not one of the choices.
+func (f Field) checkChoices(values []string) error {
+	for _, value := range values {
+		if !slices.Contains(f.Choices, value) {
+			return fmt.Errorf("%s takes %s, got %q", f.Key, strings.Join(f.Choices, ", "), value)
+		}
+	}
+	return nil
+}
+
+// splitEntries splits values on commas, so "a,b" and "a b" given as
+// arguments both name two entries. Blank entries are dropped.
+func splitEntries(values []string) []string {
+	entries := []string{}
+	for _, text := range values {
+		entries = append(entries, value{text: text}.entries()...)
+	}
+	return entries
+}
+
+// Command runs a non-interactive config subcommand. args holds the
+// subcommand and its arguments:
+//
+//	get KEY             print the value in effect, one entry per line
+//	set KEY VALUE...    replace a setting
+//	add KEY VALUE...    add entries to a list
+//	remove KEY VALUE... remove entries from a list
+//	path                print the document edited
+//
+// get reads the configuration in effect, the user document with the
+// workspace layer of root and the environment over it. The others edit the
+// user document, or the workspace layer with workspace set, in which case only
+// the settings the layer named before and the one edited are written.
+func Command(w io.Writer, root string, workspace bool, args []string) error {
+	userPath, err := Path()
+	if err != nil {
+		return err
+	}
+	path := userPath
+	if workspace {
+		path = WorkspacePath(root)
+	}
+	return command(w, userPath, WorkspacePath(root), path, args)
+}
+
+// command runs a subcommand against the user document at userPath and the
+// workspace layer at workspacePath, writing the document at path.
+func command(w io.Writer, userPath, workspacePath, path string, args []string) error {
+	if len(args) == 0 {
+		return errors.New("config: missing subcommand")
+	}
+	name, args := args[0], args[1:]
+
+	switch name {
+	case CommandPath:
+		fmt.Fprintln(w, path)
+		return nil
+	case CommandGet:
+		if len(args) != 1 {
+			return errors.New("usage: worktree config get KEY")
+		}
+		cfg, _, err := LoadLayeredFiles(userPath, workspacePath)
+		if err != nil {
+			return err
+		}
+		if err := ApplyEnv(cfg); err != nil {
+			return err
+		}
+		field, err := cfg.Field(args[0])
+		if err != nil {
+			return err
+		}
+		for _, value := range field.Values() {
+			fmt.Fprintln(w, value)
+		}
+		return nil
+	case CommandSet, CommandAdd, CommandRemove:
+		if len(args) < 1 || (name != CommandSet && len(args) < 2) {
+			return fmt.Errorf("usage: worktree config %s KEY VALUE...", name)
+		}
+	default:
+		return fmt.Errorf("config: unknown subcommand %q", name)
+	}
+
+	key, values := args[0], args[1:]
+	user, err := LoadFile(userPath)
+	if err != nil {
+		return err
+	}
+	if path == userPath {
+		return editAndSave(user, name, key, values, func(cfg *Config) error {
+			return SaveFile(path, cfg)
+		})
+	}
+
+	cfg, layer, err := Overlay(user, path)
+	if err != nil {
+		return err
+	}
+	return editAndSave(cfg, name, key, values, func(cfg *Config) error {
+		if !slices.Contains(layer, key) {
+			layer = append(layer, key)
+		}
+		return SaveLayerFile(path, cfg, layer)
+	})
+}
+
+// editAndSave applies one edit subcommand to cfg and saves it with save.
+func editAndSave(cfg *Config, name, key string, values []string, save func(*Config) error) error {
+	field, err := cfg.Field(key)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case CommandSet:
+		err = field.Set(values)
+	case CommandAdd:
+		err = field.Add(values)
+	case CommandRemove:
+		err = field.Remove(values)
+	}
+	if err != nil {
+		return err
+	}
+	return save(cfg)
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runCommand runs a config subcommand against documents in dir, editing the
+// workspace layer when workspace is set, and returns what it printed.
+func runCommand(t *testing.T, dir string, workspace bool, args ...string) (string, error) {
+	t.Helper()
+	userPath := filepath.Join(dir, "worktree.yml")
+	workspacePath := filepath.Join(dir, WorkspaceFileName)
+	path := userPath
+	if workspace {
+		path = workspacePath
+	}
+	var out bytes.Buffer
+	err := command(&out, userPath, workspacePath, path, args)
+	return out.String(), err
+}
+
+func TestCommandGetAndSet(t *testing.T) {
+	dir := t.TempDir()
+
+	if got, err := runCommand(t, dir, false, "get", "scan.root_markers"); err != nil || got != "go.work\ngo.mod\n.git\n" {
+		t.Fatalf("get scan.root_markers = %q, %v, want the defaults one per line", got, err)
+	}
+	if _, err := runCommand(t, dir, false, "set", "scan.enable_gitignore", "false"); err != nil {
+		t.Fatalf("set error: %v", err)
+	}
+	if _, err := runCommand(t, dir, false, "set", "scan.ignore_paths", "node_modules,vendor", "dist"); err != nil {
+		t.Fatalf("set error: %v", err)
+	}
+
+	cfg, err := LoadFile(filepath.Join(dir, "worktree.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Scan.EnableGitignore {
+		t.Fatal("scan.enable_gitignore is on, want set to have turned it off")
+	}
+	if want := []string{"node_modules", "vendor", "dist"}; !reflect.DeepEqual(cfg.Scan.IgnorePaths, want) {
+		t.Fatalf("scan.ignore_paths = %v, want %v", cfg.Scan.IgnorePaths, want)
+	}
+	if got, _ := runCommand(t, dir, false, "get", "scan.enable_gitignore"); got != "false\n" {
+		t.Fatalf("get scan.enable_gitignore = %q, want false", got)
+	}
+
+	// A number takes any number of days, not only the ones the form steps
+	// through.
+	if _, err := runCommand(t, dir, false, "set", "display.unreleased_days", "45"); err != nil {
+		t.Fatalf("set error: %v", err)
+	}
+	if got, _ := runCommand(t, dir, false, "get", "display.unreleased_days"); got != "45\n" {
+		t.Fatalf("get display.unreleased_days = %q, want 45", got)
+	}
+}
+
+func TestCommandAddAndRemove(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, args := range [][]string{
+		{"add", "scan.ignore_paths", "vendor", "dist"},
+		{"add", "scan.ignore_paths", "vendor"},
+		{"remove", "scan.ignore_paths", "dist", "missing"},
+		{"remove", "display.columns", "usage"},
+	} {
+		if _, err := runCommand(t, dir, false, args...); err != nil {
+			t.Fatalf("%v error: %v", args, err)
+		}
+	}
+	cfg, err := LoadFile(filepath.Join(dir, "worktree.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"vendor"}; !reflect.DeepEqual(cfg.Scan.IgnorePaths, want) {
+		t.Fatalf("scan.ignore_paths = %v, want %v", cfg.Scan.IgnorePaths, want)
+	}
+	if want := []string{"module", "latest", "go", "branch", "state"}; !reflect.DeepEqual(cfg.Display.Columns, want) {
+		t.Fatalf("display.columns = %v, want %v", cfg.Display.Columns, want)
+	}
+}
+
+func TestCommandRejects(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"frobnicate"},
+		{"get"},
+		{"get", "scan.nope"},
+		{"set", "scan.enable_gitignore", "maybe"},
+		{"set", "scan.enable_gitignore"},
+		{"set", "display.sort", "size"},
+		{"set", "display.unreleased_days", "never"},
+		{"set", "display.unreleased_days", "-1"},
+		{"set", "display.columns", "module", "nope"},
+		{"add", "scan.enable_gitignore", "true"},
+		{"remove", "scan.ignore_paths"},
+	} {
+		if _, err := runCommand(t, t.TempDir(), false, args...); err == nil {
+			t.Errorf("%v: want an error", args)
+		}
+	}
+}
+
+// TestCommandSetsTheWorkspaceLayer checks an edit of the workspace layer adds
+// the setting to the layer and leaves the user document alone.
+func TestCommandSetsTheWorkspaceLayer(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, WorkspaceFileName), "scan:\n  ignore_paths: [vendor]\n")
+
+	if _, err := runCommand(t, dir, true, "set", "display.sort", "name"); err != nil {
+		t.Fatalf("set error: %v", err)
+	}
+	data, err := LoadFile(filepath.Join(dir, "worktree.yml"))
+	if err != nil || data.Display.Sort != "usage" {
+		t.Fatalf("the user document changed: %#v, %v", data, err)
+	}
+	_, keys, err := Overlay(Default(), filepath.Join(dir, WorkspaceFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"scan.ignore_paths", "display.sort"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("the layer names %v, want %v", keys, want)
+	}
+	if got, _ := runCommand(t, dir, true, "get", "display.sort"); got != "name\n" {
+		t.Fatalf("get display.sort = %q, want the layer's value", got)
+	}
+	if got, _ := runCommand(t, dir, true, "path"); !strings.HasSuffix(strings.TrimSpace(got), WorkspaceFileName) {
+		t.Fatalf("path = %q, want the workspace layer", got)
+	}
+}
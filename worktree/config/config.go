@@ -6,9 +6,18 @@
 // a setting it does not name reads as its zero value. Every setting is
 // therefore named so that off is the zero value, and Save writes every key
 // back, so a round trip through the setup screen cannot drop one.
+//
+// A workspace can add a layer of its own, .worktree.yml at the scan root.
+// Unlike the user document the layer is partial: it overrides only the
+// settings it names, so a team commits what it shares and everyone keeps
+// their own preferences for the rest.
 package config
 
-import "slices"
+import (
+	"slices"
+	"strings"
+	"time"
+)
 
 // Version is the document version this build writes. A document declaring a
 // higher version is rejected, a document declaring none is accepted as
@@ -23,6 +32,24 @@ type Config struct {
 
 	// Scan holds the workspace scan settings.
 	Scan Scan `yaml:"scan"`
+
+	// Display holds the settings of the workspace table.
+	Display Display `yaml:"display"`
+
+	// Licenses holds the settings of the license inventory.
+	Licenses Licenses `yaml:"licenses"`
+
+	// Queries are saved --where expressions by name. A name can stand in
+	// an expression for the query it saves, as in "--where release".
+	Queries map[string]string `yaml:"queries,omitempty"`
+
+	// Groups are named sets of modules, selected as "worktree @name" and
+	// drawn as containers in the diagrams.
+	Groups Groups `yaml:"groups,omitempty"`
+
+	// Palette overrides colors of the theme by name, such as "white: 236",
+	// each an xterm-256 index or an #rrggbb color.
+	Palette map[string]string `yaml:"palette,omitempty"`
 }
 
 // Scan holds the settings of the workspace walk that collects git
@@ -45,9 +72,98 @@ type Scan struct {
 	// parent directory holding one of them is the scan root. With no
 	// markers the current directory is used.
 	RootMarkers []string `yaml:"root_markers"`
+
+	// DefaultBranches are branch names taken as the default branch of a
+	// repository that has one of them, tried in order before the branch
+	// origin/HEAD points at and a local main or master.
+	DefaultBranches []string `yaml:"default_branches"`
 }
 
 // Ignored reports whether a directory name is listed in IgnorePaths.
 func (s Scan) Ignored(name string) bool {
 	return slices.Contains(s.IgnorePaths, name)
 }
+
+// Columns lists the columns the workspace table can show.
+var Columns = []string{"module", "latest", "go", "toolchain", "branch", "state", "usage", "released", "age", "commit", "contributors"}
+
+// DefaultColumns lists the columns the workspace table shows when none are
+// configured, in order. The others are there to be picked.
+var DefaultColumns = []string{"module", "latest", "go", "branch", "state", "usage"}
+
+// SortKeys lists the orders the workspace table can be sorted in. The first
+// is the default: most used modules first, then the ones with the fewest
+// dependencies of their own, then by name.
+var SortKeys = []string{"usage", "name", "path", "ahead", "outdated"}
+
+// UnreleasedDays lists the ages, in days, the form steps the threshold of
+// unreleased commits through; 0 never flags them. Any other number of days
+// can be set in the file or with "worktree config set".
+var UnreleasedDays = []int{0, 30, 60, 90, 180, 365}
+
+// Themes lists the color themes of the tables. The first is the default.
+var Themes = []string{"dark", "light", "high-contrast", "16-color"}
+
+// Display holds the settings of the workspace table. The zero value is the
+// table as it is without a configuration.
+type Display struct {
+	// Columns are the columns shown, in order, named from Columns. Empty
+	// shows DefaultColumns.
+	Columns []string `yaml:"columns,omitempty"`
+
+	// Sort is the order of the modules, named from SortKeys. Empty sorts by
+	// usage.
+	Sort string `yaml:"sort"`
+
+	// UnreleasedDays is the age, in days, after which a module's commits
+	// since its latest tag color the Latest column. Zero never colors it.
+	UnreleasedDays int `yaml:"unreleased_days"`
+
+	// Theme is the color theme of the tables, named from Themes. Empty
+	// reads as dark.
+	Theme string `yaml:"theme"`
+
+	// ShowAll includes the modules with nothing to report, as --all does.
+	ShowAll bool `yaml:"show_all"`
+
+	// Verbose shows module details, as -v does.
+	Verbose bool `yaml:"verbose"`
+}
+
+// TableColumns returns the columns to show, the default set when none are
+// configured. Names that are not columns are dropped.
+func (d Display) TableColumns() []string {
+	if len(d.Columns) == 0 {
+		return slices.Clone(DefaultColumns)
+	}
+	var out []string
+	for _, name := range d.Columns {
+		if slices.Contains(Columns, name) && !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// UnreleasedAge returns the age after which unreleased commits are flagged,
+// zero for never.
+func (d Display) UnreleasedAge() time.Duration {
+	return time.Duration(max(d.UnreleasedDays, 0)) * 24 * time.Hour
+}
+
+// Licenses holds the settings of "worktree licenses".
+type Licenses struct {
+	// Deny lists the licenses flagged in the inventory. An entry names one
+	// license, such as GPL-3.0, or a family of them, such as GPL for every
+	// version of it. Matching ignores case.
+	Deny []string `yaml:"deny"`
+}
+
+// Denied reports whether a license identifier is on the deny list, by name
+// or by family: GPL denies GPL-2.0 and GPL-3.0, but not LGPL-2.1.
+func (l Licenses) Denied(license string) bool {
+	return slices.ContainsFunc(l.Deny, func(deny string) bool {
+		return strings.EqualFold(deny, license) ||
+			len(license) > len(deny) && strings.EqualFold(deny+"-", license[:len(deny)+1])
+	})
+}
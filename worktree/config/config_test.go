@@ -3,6 +3,7 @@ package config
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestScanIgnored(t *testing.T) {
@@ -46,3 +47,50 @@ func TestDefaultEnablesScanning(t *testing.T) {
 		t.Fatalf("Default().Scan.RootMarkers = %v, want %v", cfg.Scan.RootMarkers, want)
 	}
 }
+
+func TestDisplayTableColumns(t *testing.T) {
+	if got := (Display{}).TableColumns(); !reflect.DeepEqual(got, DefaultColumns) {
+		t.Fatalf("TableColumns() with no columns = %v, want the defaults %v", got, DefaultColumns)
+	}
+	d := Display{Columns: []string{"usage", "nope", "module", "usage"}}
+	if got, want := d.TableColumns(), []string{"usage", "module"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TableColumns() = %v, want %v", got, want)
+	}
+	if got := Default().Display.TableColumns(); !reflect.DeepEqual(got, DefaultColumns) {
+		t.Fatalf("Default().Display.TableColumns() = %v, want %v", got, DefaultColumns)
+	}
+}
+
+func TestDisplayUnreleasedAge(t *testing.T) {
+	day := 24 * time.Hour
+	for value, want := range map[int]time.Duration{
+		0:   0,
+		30:  30 * day,
+		45:  45 * day,
+		365: 365 * day,
+	} {
+		if got := (Display{UnreleasedDays: value}).UnreleasedAge(); got != want {
+			t.Errorf("UnreleasedAge(%d) = %v, want %v", value, got, want)
+		}
+	}
+	if got := Default().Display.UnreleasedAge(); got != 90*day {
+		t.Errorf("Default().Display.UnreleasedAge() = %v, want 90 days", got)
+	}
+}
+
+func TestLicensesDenied(t *testing.T) {
+	l := Licenses{Deny: []string{"gpl", "MPL-2.0"}}
+	for license, want := range map[string]bool{
+		"GPL-3.0":    true,
+		"GPL-2.0":    true,
+		"GPL":        true,
+		"LGPL-2.1":   false,
+		"GPLv3":      false,
+		"MPL-2.0":    true,
+		"Apache-2.0": false,
+	} {
+		if got := l.Denied(license); got != want {
+			t.Errorf("Denied(%q) = %v, want %v", license, got, want)
+		}
+	}
+}
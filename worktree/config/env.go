@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvPrefix starts the name of every environment variable overriding a
+// setting. The rest of the name is the setting key in upper case with its
+// dots turned into underscores, so WORKTREE_SCAN_IGNORE_PATHS overrides
+// scan.ignore_paths.
+const EnvPrefix = "WORKTREE_"
+
+// EnvName returns the environment variable overriding the setting key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// ApplyEnv overrides the settings of cfg named by environment variables, the
+// highest layer of the configuration. A list takes its entries separated by
+// commas, and a variable set to the empty string empties it.
+func ApplyEnv(cfg *Config) error {
+	return applyEnv(cfg, os.LookupEnv)
+}
+
+// applyEnv overrides the settings lookup names.
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	for _, field := range cfg.Fields() {
+		name := EnvName(field.Key)
+		text, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		values := []string{text}
+		if text == "" && (field.IsList() || field.IsOrder()) {
+			values = nil
+		}
+		if err := field.Set(values); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	if got, want := EnvName("scan.ignore_paths"), "WORKTREE_SCAN_IGNORE_PATHS"; got != want {
+		t.Fatalf("EnvName() = %q, want %q", got, want)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("WORKTREE_SCAN_ENABLE_GITIGNORE", "0")
+	t.Setenv("WORKTREE_SCAN_IGNORE_PATHS", "node_modules, vendor")
+	t.Setenv("WORKTREE_SCAN_ROOT_MARKERS", "")
+	t.Setenv("WORKTREE_DISPLAY_SORT", "outdated")
+
+	cfg := Default()
+	if err := ApplyEnv(cfg); err != nil {
+		t.Fatalf("ApplyEnv() error: %v", err)
+	}
+	if cfg.Scan.EnableGitignore {
+		t.Fatal("scan.enable_gitignore is on, want the environment to have turned it off")
+	}
+	if want := []string{"node_modules", "vendor"}; !reflect.DeepEqual(cfg.Scan.IgnorePaths, want) {
+		t.Fatalf("scan.ignore_paths = %v, want %v", cfg.Scan.IgnorePaths, want)
+	}
+	if len(cfg.Scan.RootMarkers) != 0 {
+		t.Fatalf("scan.root_markers = %v, want an empty variable to empty it", cfg.Scan.RootMarkers)
+	}
+	if cfg.Display.Sort != "outdated" || !cfg.Scan.EnableGitRepos {
+		t.Fatalf("ApplyEnv() = %#v, want only the named settings changed", cfg)
+	}
+}
+
+func TestApplyEnvRejectsBadValues(t *testing.T) {
+	t.Setenv("WORKTREE_DISPLAY_VERBOSE", "loud")
+	if err := ApplyEnv(Default()); err == nil {
+		t.Fatal("ApplyEnv() with a bad boolean, want an error")
+	}
+}
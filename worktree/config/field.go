@@ -2,6 +2,7 @@ package config
 
 import (
 	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -9,7 +10,8 @@ import (
 // held as a pointer into the Config the field was built from, so a saved form
 // writes straight through into the document.
 //
-// Exactly one of Bool and List is set, which is what IsList reports.
+// Exactly one of Bool, List, Choice, Order and Number is set. Choice and
+// Order pick from Choices.
 type Field struct {
 	// Title is the label the form shows.
 	Title string
@@ -25,13 +27,53 @@ type Field struct {
 
 	// List points at a string list setting, or is nil.
 	List *[]string
+
+	// Choice points at a setting holding one of Choices, or is nil. An empty
+	// setting reads as the first choice.
+	Choice *string
+
+	// Order points at a setting holding an ordered subset of Choices, or is
+	// nil. An empty setting reads as Defaults, or every choice without them.
+	Order *[]string
+
+	// Number points at a setting holding a whole number of 0 or more, or is
+	// nil.
+	Number *int
+
+	// Choices are the values Choice and Order pick from.
+	Choices []string
+
+	// Steps are the numbers the form steps a Number through, in increasing
+	// order. Any other number can be set outside the form.
+	Steps []int
+
+	// Zero names the value 0 of a Number in the form, such as "never".
+	Zero string
+
+	// Defaults are the choices an empty Order reads as.
+	Defaults []string
 }
 
-// IsList reports whether the field holds a string list rather than a boolean.
+// IsList reports whether the field holds a string list that is typed into.
 func (f Field) IsList() bool {
 	return f.List != nil
 }
 
+// IsChoice reports whether the field holds one of its choices.
+func (f Field) IsChoice() bool {
+	return f.Choice != nil
+}
+
+// IsOrder reports whether the field holds an ordered subset of its choices.
+func (f Field) IsOrder() bool {
+	return f.Order != nil
+}
+
+// IsNumber reports whether the field holds a whole number.
+func (f Field) IsNumber() bool {
+	return f.Number != nil
+}
+
 // value is the edited state of one setting. The form keeps one per field and
 // writes them into the document only when it saves, so leaving the form
 // discards the edits rather than the document having to be reloaded.
@@ -41,6 +83,18 @@ type value struct {
 
 	// text is a string list as it is typed, entries separated by commas.
 	text string
+
+	// choice is the value of a choice setting.
+	choice string
+
+	// order is the chosen subset of an ordered setting, in order, and pick
+	// the position of the choice being worked on, counted over the chosen
+	// entries followed by the ones left out.
+	order []string
+	pick  int
+
+	// number is the value of a number setting.
+	number int
 }
 
 // entries splits list text into the entries the document holds, dropping the
@@ -59,26 +113,136 @@ func (v value) entries() []string {
 // list, or typing a separator that adds no entry, is not an edit.
 func (v value) equal(other value, list bool) bool {
 	if !list {
-		return v.flag == other.flag
+		// Only the state of the kind of setting the values are for is set,
+		// the rest compares as zero.
+		return v.flag == other.flag && v.choice == other.choice && slices.Equal(v.order, other.order) && v.number == other.number
 	}
 	return slices.Equal(v.entries(), other.entries())
 }
 
 // state reads the setting out of the document, the value the form starts on.
 func (f Field) state() value {
-	if f.IsList() {
+	switch {
+	case f.IsList():
 		return value{text: strings.Join(*f.List, ", ")}
+	case f.IsChoice():
+		if *f.Choice == "" && len(f.Choices) > 0 {
+			return value{choice: f.Choices[0]}
+		}
+		return value{choice: *f.Choice}
+	case f.IsOrder():
+		if len(*f.Order) == 0 && f.Defaults != nil {
+			return value{order: slices.Clone(f.Defaults)}
+		}
+		if len(*f.Order) == 0 {
+			return value{order: slices.Clone(f.Choices)}
+		}
+		return value{order: slices.Clone(*f.Order)}
+	case f.IsNumber():
+		return value{number: *f.Number}
 	}
 	return value{flag: *f.Bool}
 }
 
 // apply writes an edited value back into the document.
 func (f Field) apply(v value) {
-	if f.IsList() {
+	switch {
+	case f.IsList():
 		*f.List = v.entries()
-		return
+	case f.IsChoice():
+		*f.Choice = v.choice
+	case f.IsOrder():
+		*f.Order = slices.Clone(v.order)
+	case f.IsNumber():
+		*f.Number = v.number
+	default:
+		*f.Bool = v.flag
+	}
+}
+
+// cycle moves a choice setting on by step, wrapping around the choices.
+func (f Field) cycle(v value, step int) value {
+	if len(f.Choices) == 0 {
+		return v
+	}
+	i := max(slices.Index(f.Choices, v.choice), 0)
+	v.choice = f.Choices[(i+step+len(f.Choices))%len(f.Choices)]
+	return v
+}
+
+// step moves a number setting to the next of its steps above it, or with a
+// negative step the next below it, wrapping around the steps. A number
+// between two steps moves to the nearer one in that direction.
+func (f Field) step(v value, step int) value {
+	if len(f.Steps) == 0 {
+		return v
+	}
+	if step > 0 {
+		i := slices.IndexFunc(f.Steps, func(n int) bool { return n > v.number })
+		v.number = f.Steps[max(i, 0)]
+		return v
+	}
+	below := f.Steps[len(f.Steps)-1]
+	for i := len(f.Steps) - 1; i >= 0; i-- {
+		if f.Steps[i] < v.number {
+			below = f.Steps[i]
+			break
+		}
+	}
+	v.number = below
+	return v
+}
+
+// numberText renders the value of a number setting, 0 as Zero when it is
+// named.
+func (f Field) numberText(n int) string {
+	if n == 0 && f.Zero != "" {
+		return f.Zero
+	}
+	return strconv.Itoa(n)
+}
+
+// entries returns the choices of an ordered setting the way the form lists
+// them: the chosen ones in order, then the ones left out.
+func (f Field) entries(v value) []string {
+	out := slices.Clone(v.order)
+	for _, choice := range f.Choices {
+		if !slices.Contains(out, choice) {
+			out = append(out, choice)
+		}
+	}
+	return out
+}
+
+// toggle adds the picked choice of an ordered setting to the end of the
+// chosen ones, or leaves it out.
+func (f Field) toggle(v value) value {
+	entries := f.entries(v)
+	if v.pick < 0 || v.pick >= len(entries) {
+		return v
+	}
+	picked := entries[v.pick]
+	if i := slices.Index(v.order, picked); i >= 0 {
+		v.order = slices.Delete(slices.Clone(v.order), i, i+1)
+		v.pick = len(v.order)
+		return v
+	}
+	v.order = append(slices.Clone(v.order), picked)
+	v.pick = len(v.order) - 1
+	return v
+}
+
+// move shifts the picked choice of an ordered setting by step among the
+// chosen ones, keeping it picked. A choice left out has no place to move.
+func (f Field) move(v value, step int) value {
+	to := v.pick + step
+	if v.pick >= len(v.order) || to < 0 || to >= len(v.order) {
+		return v
 	}
-	*f.Bool = v.flag
+	v.order = slices.Clone(v.order)
+	v.order[v.pick], v.order[to] = v.order[to], v.order[v.pick]
+	v.pick = to
+	return v
 }
 
 // Section is a named group of settings, one form heading.
@@ -89,7 +253,8 @@ type Section struct {
 
 // Sections returns the editable settings of the document, in the order the
 // form shows them. Every setting the document holds appears exactly once, so
-// the form covers the whole file.
+// the form covers the whole file apart from the saved queries, the groups and
+// the palette, which are written by hand.
 func (c *Config) Sections() []Section {
 	return []Section{
 		{
@@ -119,6 +284,70 @@ func (c *Config) Sections() []Section {
 					List:  &c.Scan.RootMarkers,
 					Help:  "Files marking the workspace root",
 				},
+				{
+					Title: "Default Branches",
+					Key:   "scan.default_branches",
+					List:  &c.Scan.DefaultBranches,
+					Help:  "Branch names taken as the trunk",
+				},
+			},
+		},
+		{
+			Title: "Display",
+			Fields: []Field{
+				{
+					Title:    "Columns",
+					Key:      "display.columns",
+					Order:    &c.Display.Columns,
+					Choices:  Columns,
+					Defaults: DefaultColumns,
+					Help:     "Table columns shown, in order",
+				},
+				{
+					Title:   "Sort",
+					Key:     "display.sort",
+					Choice:  &c.Display.Sort,
+					Choices: SortKeys,
+					Help:    "Order of the modules",
+				},
+				{
+					Title:  "Unreleased Days",
+					Key:    "display.unreleased_days",
+					Number: &c.Display.UnreleasedDays,
+					Steps:  UnreleasedDays,
+					Zero:   "never",
+					Help:   "Age of unreleased commits to flag",
+				},
+				{
+					Title:   "Theme",
+					Key:     "display.theme",
+					Choice:  &c.Display.Theme,
+					Choices: Themes,
+					Help:    "Colors of the tables",
+				},
+				{
+					Title: "Show All",
+					Key:   "display.show_all",
+					Bool:  &c.Display.ShowAll,
+					Help:  "List modules with nothing to do",
+				},
+				{
+					Title: "Verbose",
+					Key:   "display.verbose",
+					Bool:  &c.Display.Verbose,
+					Help:  "Show module details by default",
+				},
+			},
+		},
+		{
+			Title: "Licenses",
+			Fields: []Field{
+				{
+					Title: "Deny",
+					Key:   "licenses.deny",
+					List:  &c.Licenses.Deny,
+					Help:  "License IDs flagged by licenses",
+				},
 			},
 		},
 	}
@@ -26,8 +26,20 @@ func TestFieldsCoverEverySetting(t *testing.T) {
 		if len(field.Help) > 40 || strings.Contains(field.Help, "\n") {
 			t.Fatalf("field %q describes itself in %q, want one short line", field.Key, field.Help)
 		}
-		if (field.Bool == nil) == (field.List == nil) {
-			t.Fatalf("field %q must hold exactly one of a boolean and a list", field.Key)
+		kinds := 0
+		for _, set := range []bool{field.Bool != nil, field.List != nil, field.Choice != nil, field.Order != nil, field.Number != nil} {
+			if set {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			t.Fatalf("field %q must hold exactly one of a boolean, a list, a choice, an order and a number", field.Key)
+		}
+		if (field.IsChoice() || field.IsOrder()) != (len(field.Choices) > 0) {
+			t.Fatalf("field %q has choices it does not pick from, or picks from none", field.Key)
+		}
+		if field.IsNumber() != (len(field.Steps) > 0) {
+			t.Fatalf("field %q has steps it does not step through, or steps through none", field.Key)
 		}
 		if seen[field.Key] {
 			t.Fatalf("field %q appears twice", field.Key)
@@ -47,6 +59,14 @@ func TestFieldsCoverEverySetting(t *testing.T) {
 			t.Fatalf("no field edits scan.%s", key)
 		}
 	}
+	for _, key := range []string{"columns", "sort", "show_all", "verbose"} {
+		if !seen["display."+key] {
+			t.Fatalf("no field edits display.%s", key)
+		}
+	}
+	if !seen["licenses.deny"] {
+		t.Fatal("no field edits licenses.deny")
+	}
 }
 
 // TestFieldStateReadsTheDocument checks a field starts on the value the
@@ -133,6 +153,7 @@ func TestValueEqual(t *testing.T) {
 		{"separator half typed", value{text: "a, b"}, value{text: "a, b,"}, true, true},
 		{"entry added", value{text: "a, b"}, value{text: "a, b, c"}, true, false},
 		{"entry reordered", value{text: "a, b"}, value{text: "b, a"}, true, false},
+		{"number changed", value{number: 90}, value{number: 45}, false, false},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// Groups maps a group name to the module patterns it holds. A pattern ending
+// in "/..." matches the module it names and every module below it, as in
+// "github.com/acme/platform/..."; any other pattern is matched with
+// path.Match, so it names one module or uses wildcards.
+type Groups map[string][]string
+
+// MatchModule reports whether a module path matches a group pattern.
+func MatchModule(pattern, module string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return module == prefix || strings.HasPrefix(module, prefix+"/")
+	}
+	matched, err := path.Match(pattern, module)
+	return err == nil && matched
+}
+
+// Names returns the group names in order.
+func (g Groups) Names() []string {
+	names := make([]string, 0, len(g))
+	for name := range g {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Contains reports whether the group name holds a module.
+func (g Groups) Contains(name, module string) bool {
+	for _, pattern := range g[name] {
+		if MatchModule(pattern, module) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the first group, in name order, holding a module. It reports
+// false when no group does.
+func (g Groups) Match(module string) (string, bool) {
+	for _, name := range g.Names() {
+		if g.Contains(name, module) {
+			return name, true
+		}
+	}
+	return "", false
+}
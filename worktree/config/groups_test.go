@@ -0,0 +1,40 @@
+package config
+
+import "testing"
+
+func TestMatchModule(t *testing.T) {
+	tests := []struct {
+		pattern, module string
+		want            bool
+	}{
+		{"github.com/acme/platform/...", "github.com/acme/platform", true},
+		{"github.com/acme/platform/...", "github.com/acme/platform/auth", true},
+		{"github.com/acme/platform/...", "github.com/acme/platform/auth/v2", true},
+		{"github.com/acme/platform/...", "github.com/acme/platformer", false},
+		{"github.com/acme/cli", "github.com/acme/cli", true},
+		{"github.com/acme/cli", "github.com/acme/cli/v2", false},
+		{"github.com/acme/*-service", "github.com/acme/billing-service", true},
+		{"github.com/acme/*-service", "github.com/acme/billing/worker-service", false},
+	}
+	for _, test := range tests {
+		if got := MatchModule(test.pattern, test.module); got != test.want {
+			t.Errorf("MatchModule(%q, %q) = %v, want %v", test.pattern, test.module, got, test.want)
+		}
+	}
+}
+
+func TestGroupsMatch(t *testing.T) {
+	groups := Groups{
+		"platform": {"github.com/acme/platform/..."},
+		"apps":     {"github.com/acme/shop", "github.com/acme/platform/console"},
+	}
+	if got, ok := groups.Match("github.com/acme/platform/console"); !ok || got != "apps" {
+		t.Fatalf("Match() = %q, %v, want the first group by name", got, ok)
+	}
+	if got, ok := groups.Match("github.com/acme/platform/auth"); !ok || got != "platform" {
+		t.Fatalf("Match() = %q, %v, want platform", got, ok)
+	}
+	if _, ok := groups.Match("github.com/other/lib"); ok {
+		t.Fatal("Match() found a group for a module no pattern names")
+	}
+}
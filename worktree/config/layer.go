@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// WorkspaceFileName is the workspace layer of the configuration, relative to
+// the scan root. It is meant to be committed with the workspace, so a team
+// shares what it names while everyone keeps their own user document.
+const WorkspaceFileName = ".worktree.yml"
+
+// The layers a setting can take its value from, lowest first.
+const (
+	LayerDefault   = "default"
+	LayerUser      = "user"
+	LayerWorkspace = "workspace"
+)
+
+// Sources maps a setting key, such as "scan.ignore_paths", to the layer its
+// value came from.
+type Sources map[string]string
+
+// WorkspacePath returns the location of the workspace layer below root.
+func WorkspacePath(root string) string {
+	return filepath.Join(root, WorkspaceFileName)
+}
+
+// LoadLayered reads the user document and the workspace layer of the scan root,
+// returning the configuration in effect and the layer each setting came from.
+func LoadLayered(root string) (*Config, Sources, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, nil, err
+	}
+	return LoadLayeredFiles(path, WorkspacePath(root))
+}
+
+// LoadLayeredFiles reads the user document at userPath and layers the
+// workspace document at workspacePath over it. Either may be missing: the
+// user document falls back to the built-in defaults, and a missing workspace
+// layer changes nothing.
+func LoadLayeredFiles(userPath, workspacePath string) (*Config, Sources, error) {
+	user, err := LoadFile(userPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	base := LayerUser
+	if _, err := os.Stat(userPath); errors.Is(err, os.ErrNotExist) {
+		base = LayerDefault
+	}
+
+	cfg, keys, err := Overlay(user, workspacePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	sources := make(Sources)
+	for _, field := range cfg.Fields() {
+		sources[field.Key] = base
+		if slices.Contains(keys, field.Key) {
+			sources[field.Key] = LayerWorkspace
+		}
+	}
+	return cfg, sources, nil
+}
+
+// Overlay layers the workspace document at path over base, returning the
+// combined configuration and the keys the workspace document names. Unlike the
+// user document, a layer is partial: a setting it does not name keeps the
+// value base gives it, and a list it names replaces the list of base rather
+// than adding to it. A missing file returns a copy of base. The root markers
+// of a workspace layer are read, but have no effect on which scan root it is
+// found at.
+func Overlay(base *Config, path string) (*Config, []string, error) {
+	cfg, err := clone(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil, nil
+		}
+		return nil, nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("%s: parse config: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return cfg, nil, nil
+	}
+	var head struct {
+		Version int `yaml:"version"`
+	}
+	if err := doc.Decode(&head); err != nil {
+		return nil, nil, fmt.Errorf("%s: parse config: %w", path, err)
+	}
+	if head.Version > Version {
+		return nil, nil, fmt.Errorf("%s: config version %d is newer than this build understands (%d)", path, head.Version, Version)
+	}
+	if err := doc.Decode(cfg); err != nil {
+		return nil, nil, fmt.Errorf("%s: parse config: %w", path, err)
+	}
+	cfg.Version = base.Version
+	return cfg, leafKeys(doc.Content[0], ""), nil
+}
+
+// clone returns a deep copy of a configuration, so a layer decoded over it
+// cannot write into the slices of the one it was layered on.
+func clone(cfg *Config) (*Config, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("copy config: %w", err)
+	}
+	out := &Config{}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return nil, fmt.Errorf("copy config: %w", err)
+	}
+	return out, nil
+}
+
+// leafKeys returns the dotted keys of the settings a document node names,
+// leaving out the version, which is not a setting.
+func leafKeys(node *yaml.Node, prefix string) []string {
+	if node.Kind != yaml.MappingNode {
+		if prefix == "" || prefix == "version" {
+			return nil
+		}
+		return []string{prefix}
+	}
+	var keys []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		keys = append(keys, leafKeys(node.Content[i+1], key)...)
+	}
+	return keys
+}
+
+// SaveLayerFile writes a workspace layer to path, naming only the settings in
+// keys, so the settings the layer does not override keep coming from the user
+// document.
+func SaveLayerFile(path string, cfg *Config, keys []string) error {
+	data, err := EncodeLayer(cfg, keys)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// EncodeLayer renders a workspace layer holding the settings in keys, with the
+// version this build writes and a header saying what the file is.
+func EncodeLayer(cfg *Config, keys []string) ([]byte, error) {
+	out := *cfg
+	out.Version = Version
+
+	var doc yaml.Node
+	if err := doc.Encode(&out); err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	pruneKeys(&doc, "", keys)
+
+	var buf bytes.Buffer
+	buf.WriteString("# worktree workspace configuration, written by \"worktree config --workspace\".\n")
+	buf.WriteString("#\n")
+	buf.WriteString("# This file is layered over ~/.config/worktree.yml. Only the settings it\n")
+	buf.WriteString("# names override the user configuration.\n")
+	buf.WriteString("\n")
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pruneKeys drops the settings of a mapping node that keys does not name,
+// along with a section left empty by it. The version is always kept.
+func pruneKeys(node *yaml.Node, prefix string, keys []string) bool {
+	if node.Kind != yaml.MappingNode {
+		return prefix == "version" || slices.Contains(keys, prefix)
+	}
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if pruneKeys(node.Content[i+1], key, keys) {
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+	}
+	node.Content = content
+	return len(content) > 0
+}
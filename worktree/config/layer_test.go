@@ -0,0 +1,183 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// TestOverlayIsPartial checks a workspace layer overrides only what it names,
+// and that a list it names replaces the user list rather than extending it.
+func TestOverlayIsPartial(t *testing.T) {
+	path := filepath.Join(t.TempDir(), WorkspaceFileName)
+	writeTestFile(t, path, "scan:\n  ignore_paths: [vendor]\n")
+
+	base := Default()
+	base.Scan.IgnorePaths = []string{"node_modules", "dist"}
+
+	got, keys, err := Overlay(base, path)
+	if err != nil {
+		t.Fatalf("Overlay() error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Scan.IgnorePaths, []string{"vendor"}) {
+		t.Fatalf("Scan.IgnorePaths = %v, want the layer's list", got.Scan.IgnorePaths)
+	}
+	if !got.Scan.EnableGitignore || !reflect.DeepEqual(got.Scan.RootMarkers, base.Scan.RootMarkers) {
+		t.Fatalf("Overlay() = %#v, want the settings the layer does not name kept", got.Scan)
+	}
+	if !reflect.DeepEqual(keys, []string{"scan.ignore_paths"}) {
+		t.Fatalf("Overlay() keys = %v, want [scan.ignore_paths]", keys)
+	}
+	if !reflect.DeepEqual(base.Scan.IgnorePaths, []string{"node_modules", "dist"}) {
+		t.Fatalf("Overlay() wrote into its base: %v", base.Scan.IgnorePaths)
+	}
+}
+
+func TestOverlayMissingLayer(t *testing.T) {
+	got, keys, err := Overlay(Default(), filepath.Join(t.TempDir(), WorkspaceFileName))
+	if err != nil {
+		t.Fatalf("Overlay() error: %v", err)
+	}
+	if keys != nil || !reflect.DeepEqual(got, Default()) {
+		t.Fatalf("Overlay() without a layer = %#v, %v, want the base unchanged", got, keys)
+	}
+}
+
+func TestOverlayRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), WorkspaceFileName)
+	writeTestFile(t, path, "version: 99\n")
+
+	if _, _, err := Overlay(Default(), path); err == nil {
+		t.Fatal("Overlay() with a newer document version, want an error")
+	}
+}
+
+func TestLoadLayeredFilesSources(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "worktree.yml")
+	workspacePath := filepath.Join(dir, WorkspaceFileName)
+	writeTestFile(t, workspacePath, "scan:\n  enable_git_repos: false\n")
+
+	_, sources, err := LoadLayeredFiles(userPath, workspacePath)
+	if err != nil {
+		t.Fatalf("LoadLayeredFiles() error: %v", err)
+	}
+	if sources["scan.enable_git_repos"] != LayerWorkspace || sources["scan.enable_gitignore"] != LayerDefault {
+		t.Fatalf("sources without a user document = %v", sources)
+	}
+
+	writeTestFile(t, userPath, "scan:\n  enable_gitignore: true\n")
+	cfg, sources, err := LoadLayeredFiles(userPath, workspacePath)
+	if err != nil {
+		t.Fatalf("LoadLayeredFiles() error: %v", err)
+	}
+	if sources["scan.enable_gitignore"] != LayerUser {
+		t.Fatalf("sources with a user document = %v", sources)
+	}
+	if cfg.Scan.EnableGitRepos || !cfg.Scan.EnableGitignore {
+		t.Fatalf("LoadLayeredFiles() = %#v, want both layers applied", cfg.Scan)
+	}
+}
+
+// TestEncodeLayerNamesOnlyItsKeys checks a saved layer holds the settings it
+// overrides and nothing else, so the rest keep coming from the user document.
+func TestEncodeLayerNamesOnlyItsKeys(t *testing.T) {
+	data, err := EncodeLayer(Default(), []string{"scan.ignore_paths"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := string(data)
+	for _, want := range []string{"version: 1", "scan:", "ignore_paths:"} {
+		if !strings.Contains(document, want) {
+			t.Fatalf("EncodeLayer() missing %q:\n%s", want, document)
+		}
+	}
+	for _, unwanted := range []string{"enable_gitignore", "enable_git_repos", "root_markers"} {
+		if strings.Contains(document, unwanted) {
+			t.Fatalf("EncodeLayer() wrote %q, which the layer does not name:\n%s", unwanted, document)
+		}
+	}
+
+	data, err = EncodeLayer(Default(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "scan:") {
+		t.Fatalf("EncodeLayer() with no keys wrote an empty section:\n%s", data)
+	}
+}
+
+// TestWorkspaceModelSavesTheLayer checks the workspace form opens on the
+// configuration in effect and writes the settings the layer already named
+// plus the one edited, leaving the user document alone.
+func TestWorkspaceModelSavesTheLayer(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "worktree.yml")
+	workspacePath := filepath.Join(dir, WorkspaceFileName)
+	writeTestFile(t, userPath, "scan:\n  enable_gitignore: true\n  enable_git_repos: true\n")
+	writeTestFile(t, workspacePath, "scan:\n  ignore_paths: [vendor]\n")
+
+	m, err := newWorkspaceModelFor(userPath, workspacePath)
+	if err != nil {
+		t.Fatalf("newWorkspaceModelFor() error: %v", err)
+	}
+	if !m.config.Scan.EnableGitignore || !reflect.DeepEqual(m.config.Scan.IgnorePaths, []string{"vendor"}) {
+		t.Fatalf("the form opened on %#v, want the layers combined", m.config.Scan)
+	}
+
+	// Turn enable_git_repos off, then save.
+	m, _ = press(m, key(tea.KeyDown), key(tea.KeyLeft), key(tea.KeyF10))
+	if !m.Saved() {
+		t.Fatalf("the form did not save: %s", m.status)
+	}
+
+	data, err := os.ReadFile(workspacePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer := string(data)
+	if !strings.Contains(layer, "enable_git_repos: false") || !strings.Contains(layer, "- vendor") {
+		t.Fatalf("the layer does not hold its settings:\n%s", layer)
+	}
+	if strings.Contains(layer, "enable_gitignore") {
+		t.Fatalf("the layer took over a setting it was not edited for:\n%s", layer)
+	}
+	if user, _ := os.ReadFile(userPath); !strings.Contains(string(user), "enable_git_repos: true") {
+		t.Fatalf("the user document changed:\n%s", user)
+	}
+}
+
+func TestViewShowsSources(t *testing.T) {
+	dir := t.TempDir()
+	workspacePath := filepath.Join(dir, WorkspaceFileName)
+	writeTestFile(t, workspacePath, "scan:\n  ignore_paths: [vendor]\n")
+
+	m, err := newWorkspaceModelFor(filepath.Join(dir, "worktree.yml"), workspacePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ignore, gitignore string
+	for _, line := range renderLines(m) {
+		switch {
+		case strings.Contains(line, "Ignore Paths"):
+			ignore = line
+		case strings.Contains(line, "Enable Gitignore"):
+			gitignore = line
+		}
+	}
+	if !strings.Contains(ignore, LayerWorkspace) {
+		t.Fatalf("Ignore Paths row %q, want it marked as coming from the workspace", ignore)
+	}
+	if !strings.Contains(gitignore, LayerDefault) {
+		t.Fatalf("Enable Gitignore row %q, want it marked as a default", gitignore)
+	}
+	for _, line := range renderLines(m) {
+		if printableWidth(line) != printableWidth(renderLines(m)[0]) {
+			t.Fatalf("the form is not rectangular with a source column:\n%s", strings.Join(renderLines(m), "\n"))
+		}
+	}
+}
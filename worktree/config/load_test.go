@@ -109,6 +109,7 @@ func TestSaveFileWritesEveryKey(t *testing.T) {
 		"enable_git_repos:",
 		"ignore_paths:",
 		"root_markers:",
+		"deny:",
 	} {
 		if !strings.Contains(got, key) {
 			t.Fatalf("SaveFile() output is missing %q:\n%s", key, got)
@@ -121,10 +122,12 @@ func TestSaveFileRoundTrips(t *testing.T) {
 	want := &Config{
 		Version: Version,
 		Scan: Scan{
-			EnableGitRepos: true,
-			IgnorePaths:    []string{"node_modules"},
-			RootMarkers:    []string{"go.work"},
+			EnableGitRepos:  true,
+			IgnorePaths:     []string{"node_modules"},
+			RootMarkers:     []string{"go.work"},
+			DefaultBranches: []string{"develop"},
 		},
+		Licenses: Licenses{Deny: []string{"GPL"}},
 	}
 
 	if err := SaveFile(path, want); err != nil {
@@ -42,13 +42,25 @@ type Model struct {
 	// next key.
 	status string
 
+	// sources holds the layer each setting takes its value from, shown
+	// beside the value when a workspace layer is in play. It is nil when
+	// there is only the one document.
+	sources Sources
+
+	// layer lists the settings a workspace layer names, and is nil when the
+	// form edits the complete user document. A saved layer names these and
+	// every setting edited in the form, and nothing else.
+	layer []string
+
 	width  int
 	height int
 }
 
 // New returns the setup form for a configuration document. The document is
-// read into the form and written back only when the form saves.
+// read into the form and written back only when the form saves. The form is
+// drawn in the colors of the theme in effect.
 func New(cfg *Config, path string) Model {
+	loadStyles()
 	fields := cfg.Fields()
 	state := make([]value, len(fields))
 	for i, field := range fields {
@@ -117,14 +129,28 @@ func (m Model) onList() bool {
 // dirty reports whether the form holds an edit the document does not, which is
 // what makes saving worth doing.
 func (m Model) dirty() bool {
-	for i, v := range m.state {
-		if !v.equal(m.initial[i], m.fields[i].IsList()) {
+	for i := range m.state {
+		if m.edited(i) {
 			return true
 		}
 	}
 	return false
 }
 
+// edited reports whether the setting at index holds an edit.
+func (m Model) edited(index int) bool {
+	return !m.state[index].equal(m.initial[index], m.fields[index].IsList())
+}
+
+// source returns the layer the setting at index takes its value from. A
+// setting edited in a workspace layer form is about to come from the layer.
+func (m Model) source(index int) string {
+	if m.layer != nil && m.edited(index) {
+		return LayerWorkspace
+	}
+	return m.sources[m.fields[index].Key]
+}
+
 // focus moves the focus to a row, clamped to the form, leaving the caret at
 // the end of the text of a list setting.
 func (m *Model) focus(row int) {
@@ -166,6 +192,17 @@ func (m Model) handleKey(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 // chooseKey handles the keys of a boolean setting and of the buttons, the rows
 // that are chosen rather than typed into.
 func (m Model) chooseKey(msg tea.KeyPressMsg) Model {
+	if !m.onButtons() {
+		switch field := m.fields[m.cursor]; {
+		case field.IsChoice():
+			return m.choiceKey(msg)
+		case field.IsOrder():
+			return m.orderKey(msg)
+		case field.IsNumber():
+			return m.numberKey(msg)
+		}
+	}
+
 	switch keyName(msg) {
 	case "left", "-":
 		if m.onButtons() {
@@ -191,6 +228,67 @@ func (m Model) chooseKey(msg tea.KeyPressMsg) Model {
 	return m
 }
 
+// choiceKey handles the keys of a choice setting, which steps through its
+// choices where it stands.
+func (m Model) choiceKey(msg tea.KeyPressMsg) Model {
+	field := m.fields[m.cursor]
+	switch keyName(msg) {
+	case "left", "-":
+		m.state[m.cursor] = field.cycle(m.state[m.cursor], -1)
+	case "right", "+", " ", "space":
+		m.state[m.cursor] = field.cycle(m.state[m.cursor], 1)
+	case "home":
+		m.focus(0)
+	case "end":
+		m.focus(m.rows() - 1)
+	}
+	return m
+}
+
+// numberKey handles the keys of a number setting, the arrows and space
+// stepping it through the steps of its field.
+func (m Model) numberKey(msg tea.KeyPressMsg) Model {
+	field := m.fields[m.cursor]
+	switch keyName(msg) {
+	case "left", "-":
+		m.state[m.cursor] = field.step(m.state[m.cursor], -1)
+	case "right", "+", " ", "space":
+		m.state[m.cursor] = field.step(m.state[m.cursor], 1)
+	case "home":
+		m.focus(0)
+	case "end":
+		m.focus(m.rows() - 1)
+	}
+	return m
+}
+
+// orderKey handles the keys of an ordered setting. The arrows pick a choice,
+// space adds it to the chosen ones or leaves it out, and the shifted arrows
+// move it earlier or later among the chosen ones.
+func (m Model) orderKey(msg tea.KeyPressMsg) Model {
+	field, v := m.fields[m.cursor], m.state[m.cursor]
+	switch keyName(msg) {
+	case "left":
+		v.pick = max(v.pick-1, 0)
+	case "right":
+		v.pick = min(v.pick+1, len(field.entries(v))-1)
+	case " ", "space":
+		v = field.toggle(v)
+	case "shift+left", "<":
+		v = field.move(v, -1)
+	case "shift+right", ">":
+		v = field.move(v, 1)
+	case "home":
+		m.focus(0)
+		return m
+	case "end":
+		m.focus(m.rows() - 1)
+		return m
+	}
+	m.state[m.cursor] = v
+	return m
+}
+
 // editKey handles the keys of a string list setting, which is typed into where
 // it stands. Entries are separated by commas; the blanks that leaves while a
 // separator is being typed are dropped when the form saves.
@@ -271,7 +369,17 @@ func (m Model) save() (tea.Model, tea.Cmd) {
 	for i, field := range m.fields {
 		field.apply(m.state[i])
 	}
-	if err := SaveFile(m.path, m.config); err != nil {
+	save := func() error { return SaveFile(m.path, m.config) }
+	if m.layer != nil {
+		keys := slices.Clone(m.layer)
+		for i, field := range m.fields {
+			if m.edited(i) && !slices.Contains(keys, field.Key) {
+				keys = append(keys, field.Key)
+			}
+		}
+		save = func() error { return SaveLayerFile(m.path, m.config, keys) }
+	}
+	if err := save(); err != nil {
 		m.status = err.Error()
 		return m, nil
 	}
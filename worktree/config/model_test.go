@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -125,6 +126,92 @@ func TestModelMovesBetweenRows(t *testing.T) {
 	}
 }
 
+// focusOn moves the focus to the setting with the given key.
+func focusOn(t *testing.T, m Model, wantKey string) Model {
+	t.Helper()
+	for i, field := range m.fields {
+		if field.Key == wantKey {
+			m.focus(i)
+			return m
+		}
+	}
+	t.Fatalf("no field %q", wantKey)
+	return m
+}
+
+func TestModelCyclesChoice(t *testing.T) {
+	m := focusOn(t, New(Default(), ""), "display.sort")
+
+	if got := stateOf(t, m, "display.sort").choice; got != "usage" {
+		t.Fatalf("display.sort = %q, want the first choice", got)
+	}
+	m, _ = press(m, key(tea.KeyRight))
+	if got := stateOf(t, m, "display.sort").choice; got != "name" {
+		t.Fatalf("display.sort = %q after right, want name", got)
+	}
+	// Stepping back past the first choice wraps around to the last.
+	m, _ = press(m, key(tea.KeyLeft), key(tea.KeyLeft))
+	if got, want := stateOf(t, m, "display.sort").choice, SortKeys[len(SortKeys)-1]; got != want {
+		t.Fatalf("display.sort = %q after wrapping, want %q", got, want)
+	}
+}
+
+func TestModelStepsNumber(t *testing.T) {
+	cfg := Default()
+	cfg.Display.UnreleasedDays = 45
+	m := focusOn(t, New(cfg, ""), "display.unreleased_days")
+
+	// A number between two steps moves to the nearer one either way.
+	m, _ = press(m, key(tea.KeyRight))
+	if got := stateOf(t, m, "display.unreleased_days").number; got != 60 {
+		t.Fatalf("display.unreleased_days = %d after right, want 60", got)
+	}
+	m, _ = press(m, key(tea.KeyLeft), key(tea.KeyLeft))
+	if got := stateOf(t, m, "display.unreleased_days").number; got != 0 {
+		t.Fatalf("display.unreleased_days = %d after left twice, want 0", got)
+	}
+	if !strings.Contains(strings.Join(renderLines(m), "\n"), "◂ never ▸") {
+		t.Fatal("the form does not show 0 days as never")
+	}
+	// Stepping back past the first step wraps around to the last.
+	m, _ = press(m, key(tea.KeyLeft))
+	if got := stateOf(t, m, "display.unreleased_days").number; got != 365 {
+		t.Fatalf("display.unreleased_days = %d after wrapping, want 365", got)
+	}
+}
+
+// TestModelReordersColumns checks the columns are picked, left out and moved
+// where they stand, and that the order is what gets saved.
+func TestModelReordersColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worktree.yml")
+	m := focusOn(t, New(Default(), path), "display.columns")
+
+	// Leave out the module column, which moves it behind the chosen ones.
+	m, _ = press(m, text(' '))
+	if got := stateOf(t, m, "display.columns").order; slices.Contains(got, "module") {
+		t.Fatalf("display.columns = %v, want module left out", got)
+	}
+	// Pick usage, the last chosen column, and move it to the front.
+	m, _ = press(m, key(tea.KeyLeft))
+	for range len(Columns) {
+		m, _ = press(m, text('<'))
+	}
+	want := []string{"usage", "latest", "go", "branch", "state"}
+	if got := stateOf(t, m, "display.columns").order; !reflect.DeepEqual(got, want) {
+		t.Fatalf("display.columns = %v, want %v", got, want)
+	}
+
+	m.focus(m.saveRow())
+	m, _ = press(m, key(tea.KeyEnter))
+	got, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Display.Columns, want) {
+		t.Fatalf("saved display.columns = %v, want %v", got.Display.Columns, want)
+	}
+}
+
 // TestModelEditsListInPlace checks a string list is typed into where it
 // stands, with no dialog in the way.
 func TestModelEditsListInPlace(t *testing.T) {
@@ -3,24 +3,36 @@ package config
 import (
 	"fmt"
 	"io"
+	"os"
 
 	tea "charm.land/bubbletea/v2"
 )
 
 // Run opens the setup screen on the configuration document, writing it back
-// when the screen saves.
+// when the screen saves. With workspace set it edits the workspace layer below
+// root instead of the user document. Either way the screen shows which layer
+// each value comes from once the scan root has a workspace layer.
 //
 // A document that fails to parse still opens the screen, since that is where
 // it gets fixed, but it opens on the built-in defaults with the parse error in
 // the status line. Nothing is written until the screen is told to save, so a
 // hand written file is never quietly replaced.
-func Run(w io.Writer) error {
-	path, err := Path()
+func Run(w io.Writer, root string, workspace bool) error {
+	userPath, err := Path()
 	if err != nil {
 		return err
 	}
 
-	model, loadErr := newModelFor(path)
+	path := userPath
+	model, loadErr := newModelFor(userPath)
+	if workspace {
+		path = WorkspacePath(root)
+		model, loadErr = newWorkspaceModelFor(userPath, path)
+	} else if _, err := os.Stat(WorkspacePath(root)); err == nil {
+		if _, sources, err := LoadLayeredFiles(userPath, WorkspacePath(root)); err == nil {
+			model.sources = sources
+		}
+	}
 
 	final, err := tea.NewProgram(model).Run()
 	if err != nil {
@@ -46,3 +58,31 @@ func newModelFor(path string) (Model, error) {
 	}
 	return New(cfg, path), nil
 }
+
+// newWorkspaceModelFor builds the setup screen for the workspace layer at
+// workspacePath. The screen opens on the configuration in effect, the user
+// document with the layer over it, and saves only the settings the layer
+// names or the screen edits. A layer that cannot be parsed opens on the user
+// document with the error in the status line.
+func newWorkspaceModelFor(userPath, workspacePath string) (Model, error) {
+	cfg, sources, err := LoadLayeredFiles(userPath, workspacePath)
+	if err != nil {
+		base, userErr := LoadFile(userPath)
+		if userErr != nil {
+			base = Default()
+		}
+		model := New(base, workspacePath)
+		model.status = err.Error()
+		model.layer = []string{}
+		return model, err
+	}
+	model := New(cfg, workspacePath)
+	model.sources = sources
+	model.layer = []string{}
+	for key, layer := range sources {
+		if layer == LayerWorkspace {
+			model.layer = append(model.layer, key)
+		}
+	}
+	return model, nil
+}
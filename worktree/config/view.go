@@ -24,20 +24,35 @@ const (
 
 // The form palette, foreground only. The form paints no background of its own,
 // so it sits on the terminal background the tables printed before it use.
-const (
-	styleReset    = components.ColorReset
-	styleFrame    = components.ColorSeparator
-	styleHeading  = components.ColorAmber
-	styleLabel    = components.ColorHeader
-	styleValue    = components.ColorGreenLt
-	styleSelected = components.ColorWhite
-	styleMarked   = components.ColorYellow
-	styleHelp     = components.ColorHeader
-	styleDim      = components.ColorBorder
-	styleLegend   = components.ColorBorder
-	styleAlert    = components.ColorAmber
+var (
+	styleReset    string
+	styleFrame    string
+	styleHeading  string
+	styleLabel    string
+	styleValue    string
+	styleSelected string
+	styleMarked   string
+	styleHelp     string
+	styleDim      string
+	styleLegend   string
+	styleAlert    string
 )
 
+// loadStyles reads the form palette from the colors of the theme in effect.
+func loadStyles() {
+	styleReset = components.ColorReset
+	styleFrame = components.ColorSeparator
+	styleHeading = components.ColorAmber
+	styleLabel = components.ColorHeader
+	styleValue = components.ColorGreenLt
+	styleSelected = components.ColorWhite
+	styleMarked = components.ColorYellow
+	styleHelp = components.ColorHeader
+	styleDim = components.ColorBorder
+	styleLegend = components.ColorBorder
+	styleAlert = components.ColorAmber
+}
+
 // Form geometry. The form is only as wide as its settings need and only as
 // tall as it has rows, so it prints inline rather than taking the screen; a
 // wide terminal caps the description column rather than stretching it.
@@ -94,27 +109,51 @@ type layout struct {
 	label int
 	value int
 	desc  int
+
+	// source is the width of the column naming the layer a value comes
+	// from, zero when the form has no layers to tell apart.
+	source int
+}
+
+// sourceWidth returns the room the source column takes, its gap included.
+func (l layout) sourceWidth() int {
+	if l.source == 0 {
+		return 0
+	}
+	return l.source + columnGap
 }
 
 // inner returns the width between the frame borders, which every row of the
 // form is rendered to.
 func (l layout) inner() int {
-	return l.label + columnGap + l.value + columnGap + l.desc
+	return l.label + columnGap + l.value + columnGap + l.sourceWidth() + l.desc
 }
 
 // layout measures the form against the settings it shows, so it is only as
 // wide as they need rather than stretched to the terminal. The value column is
 // measured from the document rather than from the text being typed, so a row
-// does not move while it is edited; text past the column scrolls instead.
+// does not move while it is edited; text past the column scrolls instead. An
+// ordered setting only takes the room the descriptions leave, since it lists
+// every choice it has and scrolls to the one picked.
 func (m Model) layout() layout {
 	var l layout
+	order := 0
 	for i, field := range m.fields {
 		l.label = max(l.label, ansi.StringWidth(markerOff+field.Title))
-		l.value = max(l.value, ansi.StringWidth(valueText(field, m.initial[i])))
+		if field.IsOrder() {
+			order = max(order, ansi.StringWidth(valueText(field, m.initial[i])))
+		} else {
+			l.value = max(l.value, ansi.StringWidth(valueText(field, m.initial[i])))
+		}
 		l.desc = max(l.desc, ansi.StringWidth(field.Help))
 	}
 	l.label = max(l.label, ansi.StringWidth(markerOff+buttonSave))
 	l.value = min(max(l.value, minValueWidth), maxValueWidth)
+	if m.sources != nil {
+		for _, layer := range []string{LayerDefault, LayerUser, LayerWorkspace} {
+			l.source = max(l.source, ansi.StringWidth(layer))
+		}
+	}
 
 	width := m.width
 	if width <= 0 {
@@ -123,8 +162,11 @@ func (m Model) layout() layout {
 	// Two borders and the space inside each of them are not the form's to
 	// hand out. What is left over is taken off the descriptions first.
 	budget := min(width, maxFormWidth) - 4
-	l.desc = max(min(l.desc, budget-l.label-l.value-2*columnGap), 0)
-	l.value = min(l.value, max(budget-l.label-l.desc-2*columnGap, minValueWidth))
+	l.desc = max(min(l.desc, budget-l.label-l.value-l.sourceWidth()-2*columnGap), 0)
+	l.value = min(l.value, max(budget-l.label-l.desc-l.sourceWidth()-2*columnGap, minValueWidth))
+	if room := budget - l.label - l.desc - l.sourceWidth() - 2*columnGap; order > l.value && room > l.value {
+		l.value = min(order, room, maxValueWidth)
+	}
 	return l
 }
 
@@ -179,11 +221,24 @@ func (m Model) settingLine(index int, l layout) string {
 	if focused {
 		labelStyle, marker = styleSelected, markerOn
 	}
-	return labelStyle + truncPad(marker+field.Title, l.label) + styleReset +
+	line := labelStyle + truncPad(marker+field.Title, l.label) + styleReset +
 		strings.Repeat(" ", columnGap) +
 		m.valueCell(index, l.value) +
-		strings.Repeat(" ", columnGap) +
-		styleHelp + fit(field.Help, l.desc) + styleReset
+		strings.Repeat(" ", columnGap)
+	if l.source > 0 {
+		line += sourceCell(m.source(index), l.source) + strings.Repeat(" ", columnGap)
+	}
+	return line + styleHelp + fit(field.Help, l.desc) + styleReset
+}
+
+// sourceCell renders the layer a value comes from. A value the workspace
+// layer sets stands out, since it overrides what the user document says.
+func sourceCell(layer string, width int) string {
+	style := styleDim
+	if layer == LayerWorkspace {
+		style = styleMarked
+	}
+	return style + truncPad(layer, width) + styleReset
 }
 
 // valueCell renders the value column of a setting. The focused string list is
@@ -196,6 +251,9 @@ func (m Model) valueCell(index, width int) string {
 	if focused && field.IsList() {
 		return styleSelected + caretText(v.text, m.caret, width) + styleReset
 	}
+	if focused && field.IsOrder() {
+		return orderText(field, v, width)
+	}
 
 	style := styleValue
 	switch {
@@ -215,6 +273,14 @@ func valueText(field Field, v value) string {
 		return listEmpty
 	case field.IsList():
 		return v.text
+	case field.IsChoice():
+		return "◂ " + v.choice + " ▸"
+	case field.IsNumber():
+		return "◂ " + field.numberText(v.number) + " ▸"
+	case field.IsOrder() && len(v.order) == 0:
+		return listEmpty
+	case field.IsOrder():
+		return strings.Join(v.order, ", ")
 	case v.flag:
 		return checkOn
 	default:
@@ -237,6 +303,35 @@ func caretText(text string, caret, width int) string {
 		string(runes[caret:end]), width)
 }
 
+// orderText renders an ordered setting being worked on: the chosen entries in
+// order, then the ones left out, dimmed, with the picked entry bracketed. The
+// text is windowed to width so the picked entry stays on screen.
+func orderText(field Field, v value, width int) string {
+	var b strings.Builder
+	end := 0
+	for i, entry := range field.entries(v) {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		style := styleSelected
+		if i >= len(v.order) {
+			style = styleDim
+		}
+		if i == v.pick {
+			b.WriteString(styleMarked + "[" + entry + "]" + styleReset)
+			end = ansi.StringWidth(ansi.Strip(b.String()))
+			continue
+		}
+		b.WriteString(style + entry + styleReset)
+	}
+
+	text := b.String()
+	if cut := end - width; cut > 0 {
+		text = ansi.TruncateLeft(text, cut+1, "…")
+	}
+	return truncPad(text, width) + styleReset
+}
+
 // buttonLine renders the buttons the form is finished on. Save is dim while
 // there is nothing to write, since pressing it then does nothing but close the
 // form.
@@ -281,6 +376,10 @@ func (m Model) legend() string {
 		return "↑↓ Move   ENTER Discard   ESC Close"
 	case m.onList():
 		return "↑↓ Move   Type to edit, comma separated   ENTER Go to Save"
+	case m.fields[m.cursor].IsChoice(), m.fields[m.cursor].IsNumber():
+		return "↑↓ Move   ←→ or SPACE Choose   ENTER Go to Save"
+	case m.fields[m.cursor].IsOrder():
+		return "↑↓ Move   ←→ Pick   SPACE Show/hide   SHIFT+←→ Reorder"
 	default:
 		return "↑↓ Move   ←→ or SPACE Toggle   ENTER Go to Save"
 	}
@@ -181,7 +181,8 @@ func TestViewLegendFollowsTheFocus(t *testing.T) {
 	if got := m.legend(); !strings.Contains(got, "Nothing changed") {
 		t.Fatalf("legend on the save button of an unedited form = %q, want it to say so", got)
 	}
-	m, _ = press(m, key(tea.KeyUp), key(tea.KeyUp), key(tea.KeyUp), key(tea.KeyUp), key(tea.KeyLeft))
+	m.focus(0)
+	m, _ = press(m, key(tea.KeyLeft))
 	m.focus(m.saveRow())
 	if got := m.legend(); !strings.Contains(got, "ENTER Save") {
 		t.Fatalf("legend on the save button of an edited form = %q, want the save key", got)
@@ -236,8 +237,10 @@ func TestRenderIsInline(t *testing.T) {
 	if view := m.View(); view.AltScreen {
 		t.Fatal("View() asks for the alternate screen, want the form inline")
 	}
-	// A heading, the settings, a blank row and the buttons, inside the frame.
-	want := 1 + len(m.fields) + 1 + 1 + chromeRows
+	// A heading and a blank row per section, the settings and the buttons,
+	// inside the frame.
+	sections := len(Default().Sections())
+	want := 2*sections + len(m.fields) + 1 + chromeRows
 	if got := len(renderLines(m)); got != want {
 		t.Fatalf("render() drew %d rows, want %d", got, want)
 	}
@@ -7,14 +7,18 @@ import (
 	"strings"
 
 	"github.com/titpetric/tools/worktree/components"
+	"github.com/titpetric/tools/worktree/config"
 )
 
-func renderD2(w io.Writer, modules []moduleInfo) {
+// renderD2 draws the workspace as D2, each module in the container of its
+// group.
+func renderD2(w io.Writer, modules []moduleInfo, groups config.Groups) {
 	fmt.Fprintln(w, "direction: down")
 	fmt.Fprintln(w, "grid-columns: 1")
 	fmt.Fprintln(w)
 
-	// Group by directory path (all segments except last) as flat containers
+	// Group by configured group, or else by directory path (all segments
+	// except last), as flat containers
 	type d2Component struct {
 		key  string
 		name string
@@ -24,22 +28,17 @@ func renderD2(w io.Writer, modules []moduleInfo) {
 		uses []string
 	}
 
-	groups := make(map[string][]d2Component)
+	containers := make(map[string][]d2Component)
 	modToPkg := make(map[string]string)
 	var groupOrder []string
 	for _, m := range modules {
-		short := components.ShortPath(m.Name)
-		idx := strings.LastIndex(short, "/")
-		pkg, name := "local", short
-		if idx != -1 {
-			pkg, name = short[:idx], short[idx+1:]
-		}
-		if _, seen := groups[pkg]; !seen {
+		pkg, name := diagramGroup(m.Name, groups)
+		if _, seen := containers[pkg]; !seen {
 			groupOrder = append(groupOrder, pkg)
 		}
 		modToPkg[m.Name] = pkg
 		comp := d2Component{
-			key:  d2Key(short[idx+1:]),
+			key:  d2Key(name),
 			name: name,
 			mod:  m.Name,
 			pkg:  pkg,
@@ -50,19 +49,19 @@ func renderD2(w io.Writer, modules []moduleInfo) {
 				comp.desc = after
 			}
 		}
-		groups[pkg] = append(groups[pkg], comp)
+		containers[pkg] = append(containers[pkg], comp)
 	}
 
 	// Sort groups by module count descending
 	sort.Slice(groupOrder, func(i, j int) bool {
-		return len(groups[groupOrder[i]]) > len(groups[groupOrder[j]])
+		return len(containers[groupOrder[i]]) > len(containers[groupOrder[j]])
 	})
 
 	// Build a map from module name to container.key path
 	modToPath := make(map[string]string)
 	for _, pkg := range groupOrder {
 		containerKey := d2Key(pkg)
-		for _, c := range groups[pkg] {
+		for _, c := range containers[pkg] {
 			modToPath[c.mod] = containerKey + "." + c.key
 		}
 	}
@@ -70,7 +69,7 @@ func renderD2(w io.Writer, modules []moduleInfo) {
 	// Track cross-package imports for each module (who imports it)
 	crossPkgImports := make(map[string][]string)
 	for _, pkg := range groupOrder {
-		for _, c := range groups[pkg] {
+		for _, c := range containers[pkg] {
 			for _, dep := range c.uses {
 				if depPkg, ok := modToPkg[dep]; ok && depPkg != pkg {
 					crossPkgImports[dep] = append(crossPkgImports[dep], c.name)
@@ -84,7 +83,7 @@ func renderD2(w io.Writer, modules []moduleInfo) {
 		containerKey := d2Key(pkg)
 		fmt.Fprintf(w, "%s: %s {\n", containerKey, pkg)
 		fmt.Fprintln(w, "  style.fill: \"#f8f9fa\"")
-		for _, c := range groups[pkg] {
+		for _, c := range containers[pkg] {
 			if c.desc != "" {
 				fmt.Fprintf(w, "  %s: \"%s\\n%s\"\n", c.key, c.name, c.desc)
 			} else {
@@ -114,7 +113,7 @@ func renderD2(w io.Writer, modules []moduleInfo) {
 	fmt.Fprintln(w)
 	for _, pkg := range groupOrder {
 		containerKey := d2Key(pkg)
-		for _, c := range groups[pkg] {
+		for _, c := range containers[pkg] {
 			fromPath := containerKey + "." + c.key
 			for _, dep := range c.uses {
 				depPkg := modToPkg[dep]
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// commandDeps lists the third-party dependencies the workspace requires at
+// more than one version.
+const commandDeps = "deps"
+
+// drift is a dependency outside the workspace that workspace modules require
+// at different versions.
+type drift struct {
+	path    string
+	highest string
+
+	// versions maps each workspace module requiring the dependency to the
+	// version it requires.
+	versions map[string]string
+}
+
+// lagging returns the workspace modules requiring the dependency below its
+// highest version, sorted.
+func (d drift) lagging() []string {
+	var modules []string
+	for module, version := range d.versions {
+		if version != d.highest {
+			modules = append(modules, module)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// collectDrift reads the requirements of every workspace module and returns
+// the dependencies outside the workspace that are not required at one version
+// throughout, sorted by path. Versions are ordered as the go tool orders
+// them, so a pseudo-version sorts below the release it precedes.
+func collectDrift(modPaths map[string]string) ([]drift, error) {
+	byPath := make(map[string]map[string]string)
+	for module, dir := range modPaths {
+		reqs, err := readRequiresVersioned(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read requires for %s: %w", module, err)
+		}
+		for _, r := range reqs {
+			if _, internal := modPaths[r.path]; internal {
+				continue
+			}
+			if byPath[r.path] == nil {
+				byPath[r.path] = make(map[string]string)
+			}
+			byPath[r.path][module] = r.version
+		}
+	}
+
+	var drifts []drift
+	for path, versions := range byPath {
+		d := drift{path: path, versions: versions}
+		distinct := make(map[string]bool)
+		for _, version := range versions {
+			distinct[version] = true
+			if d.highest == "" || semver.Compare(version, d.highest) > 0 {
+				d.highest = version
+			}
+		}
+		if len(distinct) > 1 {
+			drifts = append(drifts, d)
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].path < drifts[j].path })
+	return drifts, nil
+}
+
+// renderDrift lists each drifting dependency with its highest version and the
+// modules lagging behind it.
+func renderDrift(w io.Writer, drifts []drift, styled bool) {
+	if len(drifts) == 0 {
+		fmt.Fprintln(w, "Every third-party dependency is required at one version.")
+		return
+	}
+	var rows [][]string
+	for _, d := range drifts {
+		var lagging []string
+		for _, module := range d.lagging() {
+			lagging = append(lagging, components.ShortPath(module)+" "+d.versions[module])
+		}
+		rows = append(rows, []string{
+			d.path,
+			colorLines(d.highest, components.ColorGreen, styled),
+			colorLines(strings.Join(lagging, "\n"), components.ColorAmber, styled),
+		})
+	}
+	writeSimpleTable(w, []string{"Dependency", "Highest", "Lagging"}, rows, styled)
+
+	borderColor, yellow, reset := "", "", ""
+	if styled {
+		borderColor, yellow, reset = components.ColorBorder, components.ColorYellow, components.ColorReset
+	}
+	fmt.Fprintf(w, "%srun with %s--align%s %sto require the highest version of %d dependencies everywhere%s\n",
+		borderColor, yellow, reset, borderColor, len(drifts), reset)
+}
+
+// alignTargets returns what aligning the drift takes: the version each
+// drifting dependency moves to, in the form updateDeps moves workspace
+// modules to their latest tag with, and the directories of the modules that
+// lag behind on any of them.
+func alignTargets(drifts []drift, modPaths map[string]string) (latestTags, map[string]string) {
+	targets := make(latestTags)
+	lagging := make(map[string]string)
+	for _, d := range drifts {
+		targets[d.path] = d.highest
+		for _, module := range d.lagging() {
+			lagging[module] = modPaths[module]
+		}
+	}
+	return targets, lagging
+}
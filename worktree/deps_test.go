@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// driftWorkspace writes three workspace modules requiring golang.org/x/tools
+// at different versions, one of them a pseudo-version, and a dependency they
+// agree on.
+func driftWorkspace(t *testing.T) map[string]string {
+	t.Helper()
+	root := t.TempDir()
+	modules := map[string]string{
+		"example.com/api":  "require (\n\tgolang.org/x/tools v0.30.0\n\tgolang.org/x/text v0.20.0\n\texample.com/core v1.0.0\n)\n",
+		"example.com/core": "require (\n\tgolang.org/x/tools v0.28.0\n\tgolang.org/x/text v0.20.0\n)\n",
+		"example.com/cli":  "require golang.org/x/tools v0.30.1-0.20250101000000-abcdefabcdef\n",
+	}
+	modPaths := make(map[string]string)
+	for module, requires := range modules {
+		dir := filepath.Join(root, strings.TrimPrefix(module, "example.com/"))
+		writeTestFile(t, filepath.Join(dir, "go.mod"), "module "+module+"\n\ngo 1.25\n\n"+requires)
+		modPaths[module] = dir
+	}
+	return modPaths
+}
+
+func TestCollectDrift(t *testing.T) {
+	modPaths := driftWorkspace(t)
+
+	drifts, err := collectDrift(modPaths)
+	if err != nil {
+		t.Fatalf("collectDrift() error: %v", err)
+	}
+	if len(drifts) != 1 || drifts[0].path != "golang.org/x/tools" {
+		t.Fatalf("collectDrift() = %#v, want only golang.org/x/tools", drifts)
+	}
+	d := drifts[0]
+	if d.highest != "v0.30.1-0.20250101000000-abcdefabcdef" {
+		t.Fatalf("highest = %q, want the pseudo-version above v0.30.0", d.highest)
+	}
+	if want := []string{"example.com/api", "example.com/core"}; !reflect.DeepEqual(d.lagging(), want) {
+		t.Fatalf("lagging() = %v, want %v", d.lagging(), want)
+	}
+
+	targets, lagging := alignTargets(drifts, modPaths)
+	if !reflect.DeepEqual(targets, latestTags{"golang.org/x/tools": d.highest}) {
+		t.Fatalf("alignTargets() targets = %v", targets)
+	}
+	if len(lagging) != 2 || lagging["example.com/core"] != modPaths["example.com/core"] {
+		t.Fatalf("alignTargets() lagging = %v", lagging)
+	}
+	// The aligned version is what updateDeps moves a lagging module to.
+	reqs, err := readRequiresVersioned(modPaths["example.com/core"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := staleRequires(reqs, targets); len(got) != 1 || got[0].version != d.highest {
+		t.Fatalf("staleRequires() = %v, want golang.org/x/tools at %s", got, d.highest)
+	}
+}
+
+func TestRenderDrift(t *testing.T) {
+	drifts, err := collectDrift(driftWorkspace(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	renderDrift(&output, drifts, false)
+	want := "| Dependency | Highest | Lagging |\n" +
+		"| --- | --- | --- |\n" +
+		"| golang.org/x/tools | v0.30.1-0.20250101000000-abcdefabcdef | example.com/api v0.30.0<br>example.com/core v0.28.0 |\n"
+	if got := output.String(); !strings.HasPrefix(got, want) || !strings.Contains(got, "--align") {
+		t.Fatalf("renderDrift() =\n%s\nwant:\n%s", got, want)
+	}
+
+	output.Reset()
+	renderDrift(&output, nil, false)
+	if got := output.String(); !strings.Contains(got, "one version") {
+		t.Fatalf("renderDrift() without drift = %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// renderGFM writes the workspace as GitHub-flavoured markdown for a pull
+// request comment: the summary table, the notes below it as a list, and a
+// collapsed <details> block per module with what the verbose table shows.
+func renderGFM(w io.Writer, modules []moduleInfo, opts *Options) {
+	_, columns, headers := selectColumns(opts.Columns)
+	ctx := newCellContext(modules, opts, false)
+	visible := visibleModules(modules, opts)
+
+	var rows []components.Rows
+	for _, m := range visible {
+		cells := make(components.Rows, len(columns))
+		for i, column := range columns {
+			cells[i] = column.cell(m, ctx)
+		}
+		rows = append(rows, cells)
+	}
+	writeMarkdownTable(w, headers, rows)
+
+	// A line right below a table would be read as one more row of it.
+	var notes bytes.Buffer
+	renderTableFooter(&notes, modules, opts, false)
+	if notes.Len() > 0 {
+		fmt.Fprintln(w)
+		for _, line := range strings.Split(strings.TrimRight(notes.String(), "\n"), "\n") {
+			fmt.Fprintln(w, "- "+html.EscapeString(line))
+		}
+	}
+
+	for _, m := range visible {
+		if details := gfmDetails(m); details != "" {
+			fmt.Fprintln(w)
+			fmt.Fprint(w, details)
+		}
+	}
+}
+
+// gfmSummary returns the one line a module's <details> block shows folded:
+// its folder, module path and latest tag, and its commits since the tag.
+func gfmSummary(m moduleInfo) string {
+	summary := "<code>" + html.EscapeString(m.Path) + "</code> " + html.EscapeString(components.ShortPath(m.Name))
+	if m.Latest != "" {
+		summary += " " + html.EscapeString(m.Latest)
+	}
+	if ahead := gitAhead(m); ahead > 0 {
+		summary += fmt.Sprintf(", %d commits since release", ahead)
+	}
+	return summary
+}
+
+// gfmDetails returns the <details> block of a module: its commits since the
+// release, local changes, untracked files, open issues and usage. A module
+// with none of them gets no block.
+func gfmDetails(m moduleInfo) string {
+	var sections []string
+	section := func(title string, items []string) {
+		if len(items) > 0 {
+			sections = append(sections, "**"+title+"**\n\n"+strings.Join(items, "\n")+"\n")
+		}
+	}
+
+	if g := m.GitState; g != nil {
+		var commits, changes, untracked, issues []string
+		for _, msg := range g.Msgs {
+			if hash, subject, ok := strings.Cut(msg, " "); ok {
+				commits = append(commits, "- `"+hash+"` "+html.EscapeString(subject))
+			} else {
+				commits = append(commits, "- "+html.EscapeString(msg))
+			}
+		}
+		for _, line := range g.DiffLines {
+			changes = append(changes, gfmFileItem(line))
+		}
+		for _, f := range g.UntrackedFiles {
+			untracked = append(untracked, gfmFileItem(f))
+		}
+		for _, issue := range g.Issues {
+			issues = append(issues, fmt.Sprintf("- #%s %s (%s)", issue.ID, html.EscapeString(issue.Title), issue.Date))
+		}
+		section("Commits since release", commits)
+		section("Local changes", changes)
+		section("Untracked files", untracked)
+		section("Open issues", issues)
+	}
+
+	var usage []string
+	if len(m.Usage.UsedBy) > 0 {
+		var names []string
+		for _, d := range m.Usage.UsedBy {
+			name := d.Name
+			switch {
+			case d.Retracted != "":
+				name += " (requires retracted " + d.Retracted + ")"
+			case d.Outdated:
+				name += " (outdated)"
+			}
+			names = append(names, name)
+		}
+		usage = append(usage, "- Used by: "+html.EscapeString(strings.Join(names, ", ")))
+	}
+	if len(m.Usage.Uses) > 0 {
+		usage = append(usage, "- Uses: "+html.EscapeString(strings.Join(m.Usage.Uses, ", ")))
+	}
+	section("Usage", usage)
+
+	if len(sections) == 0 {
+		return ""
+	}
+	return "<details>\n<summary>" + gfmSummary(m) + "</summary>\n\n" + strings.Join(sections, "\n") + "\n</details>\n"
+}
+
+// gfmFileItem formats a changed file as a list item, the file name as code
+// and its line counts after it.
+func gfmFileItem(line string) string {
+	line = ansi.Strip(line)
+	i := strings.LastIndex(line, " ")
+	if i < 0 {
+		return "- `" + line + "`"
+	}
+	return "- `" + line[:i] + "` " + line[i+1:]
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+func TestRenderGFM(t *testing.T) {
+	modules := []moduleInfo{
+		{
+			Name:   "example.com/lib",
+			Path:   "./lib",
+			Latest: "v1.2.0",
+			GitState: &components.Git{
+				BranchName:     "main",
+				Ahead:          2,
+				Msgs:           []string{"abc1234 fix: handle <nil> options", "def5678 feat: add the export"},
+				DiffLines:      []string{"go.mod " + components.ColorGreen + "+1" + components.ColorReset + "/" + components.ColorRed + "-1" + components.ColorReset},
+				UntrackedFiles: []string{"NOTES.md"},
+			},
+			Usage:    components.Usage{UsedBy: []components.Dependent{{Name: "app", Outdated: true}}},
+			Outdated: 1,
+		},
+		{Name: "example.com/app", Path: "./app", GitState: &components.Git{BranchName: "main"}, Usage: components.Usage{Uses: []string{"lib"}}},
+	}
+
+	var out strings.Builder
+	renderGFM(&out, modules, &Options{Columns: []string{"module", "latest"}})
+
+	want := "| Module | Latest |\n" +
+		"| --- | --- |\n" +
+		"| ./lib | v1.2.0 |\n" +
+		"\n" +
+		"- run with -u to update 1 outdated dependencies in workspace\n" +
+		"- Skipped 1 modules, use --all to show\n" +
+		"\n" +
+		"<details>\n" +
+		"<summary><code>./lib</code> example.com/lib v1.2.0, 2 commits since release</summary>\n" +
+		"\n" +
+		"**Commits since release**\n" +
+		"\n" +
+		"- `abc1234` fix: handle &lt;nil&gt; options\n" +
+		"- `def5678` feat: add the export\n" +
+		"\n" +
+		"**Local changes**\n" +
+		"\n" +
+		"- `go.mod` +1/-1\n" +
+		"\n" +
+		"**Untracked files**\n" +
+		"\n" +
+		"- `NOTES.md`\n" +
+		"\n" +
+		"**Usage**\n" +
+		"\n" +
+		"- Used by: app (outdated)\n" +
+		"\n" +
+		"</details>\n"
+	if got := out.String(); got != want {
+		t.Fatalf("renderGFM() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGFMDetailsEmpty(t *testing.T) {
+	if got := gfmDetails(moduleInfo{Name: "example.com/tool", Path: "./tool"}); got != "" {
+		t.Fatalf("gfmDetails() = %q, want no block for a module with nothing to show", got)
+	}
+}
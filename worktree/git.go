@@ -9,8 +9,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/titpetric/tools/worktree/components"
@@ -96,7 +98,23 @@ func getGitStatus(dir string) *gitStatus {
 		}
 	}
 
-	if st.Unpushed == 0 && st.Modified == 0 && len(st.DiffLines) == 0 {
+	// Count commits on the upstream not merged yet, as of the last fetch
+	args = []string{"log", "--oneline", "HEAD..@{u}"}
+	if isSubdir {
+		args = append(args, "--", relPath)
+	}
+	cmd = exec.Command("git", args...)
+	cmd.Dir = gitRoot
+	out, err = cmd.Output()
+	if err == nil {
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if line != "" {
+				st.Behind++
+			}
+		}
+	}
+
+	if st.Unpushed == 0 && st.Behind == 0 && st.Modified == 0 && len(st.DiffLines) == 0 {
 		return nil
 	}
 	return st
@@ -183,6 +201,74 @@ func countLines(path string) int {
 	return n
 }
 
+// gitTopLevel returns the root of the git repository containing dir.
+func gitTopLevel(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitRepos returns the sorted roots of the git repositories holding dirs, each
+// listed once however many of the dirs it holds. A dir outside any git
+// repository is skipped.
+func gitRepos(dirs []string) []string {
+	repos := make(map[string]struct{})
+	for _, dir := range dirs {
+		root, err := gitTopLevel(dir)
+		if err != nil {
+			continue
+		}
+		repos[root] = struct{}{}
+	}
+
+	paths := make([]string, 0, len(repos))
+	for path := range repos {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// maxFetches bounds the fetches fetchRepos runs at once, so a large
+// workspace does not open more connections than forges and sshd accept.
+const maxFetches = 8
+
+// fetchRepos runs git fetch --prune in the repositories, up to maxFetches at
+// once, returning the repositories that failed to fetch with the reason. The
+// upstream counts read afterwards are then as fresh as the remotes.
+func fetchRepos(repos []string) map[string]error {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		slots  = make(chan struct{}, maxFetches)
+		failed = make(map[string]error)
+	)
+	for _, path := range repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			cmd := exec.Command("git", "fetch", "--quiet", "--prune")
+			cmd.Dir = path
+			if out, err := cmd.CombinedOutput(); err != nil {
+				if text := strings.TrimSpace(string(out)); text != "" {
+					err = fmt.Errorf("%s", text)
+				}
+				mu.Lock()
+				failed[path] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return failed
+}
+
 func getGitBranch(dir string) string {
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = dir
@@ -193,22 +279,40 @@ func getGitBranch(dir string) string {
 	return strings.TrimSpace(string(out))
 }
 
-func latestGitTag(dir string) string {
-	cmd := exec.Command("git", "tag", "--list", "--sort=-v:refname", "v*")
+// latestGitTag returns the highest version of the module tagged with prefix,
+// with the prefix cut; see gitTagPrefix and moduleVersions.
+func latestGitTag(dir, prefix, major string) string {
+	return latestTagMerged(dir, prefix, major, "")
+}
+
+// latestTagMerged returns what latestGitTag does, counting only the tags
+// reachable from commit when it is given.
+func latestTagMerged(dir, prefix, major, commit string) string {
+	args := []string{"tag", "--list", "--sort=-v:refname"}
+	if commit != "" {
+		args = append(args, "--merged", commit)
+	}
+	cmd := exec.Command("git", append(args, prefix+"v*")...)
 	cmd.Dir = dir
 	out, err := cmd.Output()
 	if err != nil {
 		return ""
 	}
+	var tags []string
 	scanner := bufio.NewScanner(strings.NewReader(string(out)))
-	if scanner.Scan() {
-		return strings.TrimSpace(scanner.Text())
+	for scanner.Scan() {
+		tags = append(tags, strings.TrimSpace(scanner.Text()))
+	}
+	if versions := moduleVersions(tags, prefix, major); len(versions) > 0 {
+		return versions[0]
 	}
 	return ""
 }
 
-func commitsSinceTag(dir, tag string) int {
-	cmd := exec.Command("git", "rev-list", "--count", tag+"..HEAD", "--", ".")
+// commitsSinceTag counts the commits since tag within the pathspec spec of
+// the module in dir, so commits to nested modules are theirs.
+func commitsSinceTag(dir string, spec []string, tag string) int {
+	cmd := exec.Command("git", append([]string{"rev-list", "--count", tag + "..HEAD"}, spec...)...)
 	cmd.Dir = dir
 	out, err := cmd.Output()
 	if err != nil {
@@ -218,20 +322,10 @@ func commitsSinceTag(dir, tag string) int {
 	return n
 }
 
-func commitMessagesSinceTag(dir, tag string) []string {
-	cmd := exec.Command("git", "log", "--oneline", tag+"..HEAD", "--", ".")
-	cmd.Dir = dir
-	out, err := cmd.Output()
-	if err != nil {
-		return nil
-	}
-	var msgs []string
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		if line != "" {
-			msgs = append(msgs, line)
-		}
-	}
-	return msgs
+// commitMessagesSinceTag lists the commits since tag within the pathspec
+// spec of the module in dir, as commitMessagesBetween does.
+func commitMessagesSinceTag(dir string, spec []string, tag string) []string {
+	return commitMessagesBetween(dir, spec, tag, "HEAD")
 }
 
 func getGitHubIssues(dir string) []components.Issue {
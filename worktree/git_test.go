@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// TestFetchReposCountsBehind checks a checkout learns of upstream commits only
+// once fetched, and that a local commit on top makes it diverged.
+func TestFetchReposCountsBehind(t *testing.T) {
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	repo := filepath.Join(root, "service")
+	runGit(t, root, "init", "--bare", remote)
+	runGit(t, root, "clone", remote, repo)
+	runGit(t, repo, "config", "user.name", "Test User")
+	runGit(t, repo, "config", "user.email", "test@example.com")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, repo, "push", "-u", "origin", "HEAD")
+
+	updater := filepath.Join(root, "updater")
+	runGit(t, root, "clone", remote, updater)
+	runGit(t, updater, "config", "user.name", "Test User")
+	runGit(t, updater, "config", "user.email", "test@example.com")
+	runGit(t, updater, "commit", "--allow-empty", "-m", "one")
+	runGit(t, updater, "commit", "--allow-empty", "-m", "two")
+	runGit(t, updater, "push")
+
+	if st := getGitStatus(repo); st != nil {
+		t.Fatalf("getGitStatus() before fetching = %#v, want nothing to report", st)
+	}
+	if failed := fetchRepos([]string{repo, filepath.Join(root, "missing")}); len(failed) != 1 {
+		t.Fatalf("fetchRepos() failed = %v, want only the missing repository", failed)
+	}
+	st := getGitStatus(repo)
+	if st == nil || st.Behind != 2 || st.Unpushed != 0 {
+		t.Fatalf("getGitStatus() after fetching = %#v, want 2 behind", st)
+	}
+
+	runGit(t, repo, "commit", "--allow-empty", "-m", "local")
+	st = getGitStatus(repo)
+	if st == nil || st.Behind != 2 || st.Unpushed != 1 {
+		t.Fatalf("getGitStatus() with a local commit = %#v, want 1 unpushed, 2 behind", st)
+	}
+}
+
+func TestGitBranchShowsBehind(t *testing.T) {
+	tests := []struct {
+		git  components.Git
+		want string
+	}{
+		{components.Git{BranchName: "main"}, "main"},
+		{components.Git{BranchName: "main", Behind: 3}, "main (-3 behind)"},
+		{components.Git{BranchName: "main", Ahead: 1, Behind: 3}, "main (+1 ahead) (-3 behind)"},
+		{components.Git{BranchName: "main", Unpushed: 2, Behind: 3}, "main (diverged ↑2 ↓3)"},
+		{components.Git{BranchName: "master", DefaultBranch: "master"}, "master"},
+		{components.Git{BranchName: "feature", DefaultBranch: "master", TrunkAhead: 2}, "feature (2 ahead of master)"},
+		{components.Git{BranchName: "feature", DefaultBranch: "main", TrunkAhead: 2, TrunkBehind: 60}, "feature (2 ahead, 60 behind main)"},
+		{components.Git{BranchName: "feature", DefaultBranch: "main", TrunkBehind: 4}, "feature (0 ahead, 4 behind main)"},
+		{components.Git{BranchName: "feature", DefaultBranch: "main", TrunkBehind: 4, TrunkMerged: true}, "feature (merged into main)"},
+	}
+	for _, test := range tests {
+		got := ansi.Strip(strings.Join(test.git.Branch(), "\n"))
+		if got != test.want {
+			t.Errorf("Branch() = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestGitBranchColorsTrunk(t *testing.T) {
+	trunk := components.Git{BranchName: "master", DefaultBranch: "master"}
+	if got := trunk.Branch()[0]; !strings.HasPrefix(got, components.ColorTeal+"master") {
+		t.Errorf("Branch() of the default branch = %q, want it teal", got)
+	}
+	feature := components.Git{BranchName: "main", DefaultBranch: "develop", TrunkAhead: 1}
+	if got := feature.Branch()[0]; !strings.HasPrefix(got, components.ColorAmber+"main") {
+		t.Errorf("Branch() of a feature branch = %q, want it amber", got)
+	}
+	far := components.Git{BranchName: "feature", DefaultBranch: "main", TrunkAhead: 1, TrunkBehind: components.FarBehind}
+	if got := far.Branch()[0]; !strings.Contains(got, components.ColorRed+"50 behind") {
+		t.Errorf("Branch() far behind the trunk = %q, want the count red", got)
+	}
+}
@@ -62,6 +62,22 @@ func readGoVersion(dir string) string {
 	return mod.Go.Version
 }
 
+// readToolchain returns the toolchain directive of the go.mod in dir, empty
+// when it has none.
+func readToolchain(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+
+	mod, err := modfile.Parse("go.mod", data, nil)
+	if err != nil || mod.Toolchain == nil {
+		return ""
+	}
+
+	return mod.Toolchain.Name
+}
+
 func readReadmeTitle(dir string) string {
 	f, err := os.Open(filepath.Join(dir, "README.md"))
 	if err != nil {
@@ -140,19 +140,43 @@ func findGoWorkFiles(root string, scan config.Scan) []string {
 	return files
 }
 
-// updateGoWorkVersions sets the go directive of every go.work file under root,
-// reporting the files it changed.
-func updateGoWorkVersions(w io.Writer, root, goVersion string, scan config.Scan, styled bool) error {
+// updateGoWorkFiles sets the go and toolchain directives of every go.work
+// file under root to opts.GoVersion and opts.Toolchain, where given,
+// reporting the files it changed and returning their updates to commit. As
+// in setDirectives, the toolchain change is reported against the directive
+// the file had before, so one the new go directive drops is reported too.
+func updateGoWorkFiles(w io.Writer, root string, opts *Options, scan config.Scan, styled bool) ([]moduleUpdate, error) {
+	var updates []moduleUpdate
 	for _, path := range findGoWorkFiles(root, scan) {
-		before, err := setGoWorkVersion(path, goVersion)
-		if err != nil {
-			return fmt.Errorf("failed to update %s: %w", relPath(path), err)
+		update := moduleUpdate{dir: filepath.Dir(path), modPath: relPath(path), work: true}
+		toolchain := readWorkToolchain(path)
+		var lines []string
+		if opts.GoVersion != "" {
+			before, err := setGoWorkVersion(path, opts.GoVersion)
+			if err != nil {
+				return updates, fmt.Errorf("failed to update %s: %w", relPath(path), err)
+			}
+			if before != opts.GoVersion {
+				lines = append(lines, goVersionChange(before, opts.GoVersion))
+				update.goFrom, update.goTo = before, opts.GoVersion
+			}
+		}
+		if opts.Toolchain != "" {
+			if _, err := setGoWorkToolchain(path, opts.Toolchain); err != nil {
+				return updates, fmt.Errorf("failed to update %s: %w", relPath(path), err)
+			}
+		}
+		if next := readWorkToolchain(path); next != toolchain {
+			lines = append(lines, toolchainChange(toolchain, next))
+			update.toolchainFrom, update.toolchainTo = toolchain, next
 		}
-		if before == goVersion {
+		if len(lines) == 0 {
 			continue
 		}
-		line := relPath(path) + ": " + goVersionChange(before, goVersion)
-		fmt.Fprintln(w, colorLines(line, components.ColorAmber, styled))
+		for _, line := range lines {
+			fmt.Fprintln(w, colorLines(relPath(path)+": "+line, components.ColorAmber, styled))
+		}
+		updates = append(updates, update)
 	}
-	return nil
+	return updates, nil
 }
@@ -136,27 +136,33 @@ func TestFindGoWorkFiles(t *testing.T) {
 	}
 }
 
-func TestUpdateGoWorkVersions(t *testing.T) {
+func TestUpdateGoWorkFilesGoVersion(t *testing.T) {
 	root := t.TempDir()
 	writeTestFile(t, filepath.Join(root, "go.work"), "go 1.25\n\nuse ./app\n")
 	writeTestFile(t, filepath.Join(root, "app", "go.mod"), "module example.com/app\n\ngo 1.25\n")
 	chdir(t, root)
 
 	var output bytes.Buffer
-	if err := updateGoWorkVersions(&output, ".", "1.27", config.Default().Scan, false); err != nil {
-		t.Fatalf("updateGoWorkVersions() error: %v", err)
+	updates, err := updateGoWorkFiles(&output, ".", &Options{GoVersion: "1.27"}, config.Default().Scan, false)
+	if err != nil {
+		t.Fatalf("updateGoWorkFiles() error: %v", err)
 	}
 	if got, want := output.String(), "./go.work: go 1.25 → 1.27\n"; got != want {
-		t.Fatalf("updateGoWorkVersions() = %q, want %q", got, want)
+		t.Fatalf("updateGoWorkFiles() = %q, want %q", got, want)
+	}
+	want := []moduleUpdate{{dir: ".", modPath: "./go.work", work: true, goFrom: "1.25", goTo: "1.27"}}
+	if !reflect.DeepEqual(updates, want) {
+		t.Fatalf("updateGoWorkFiles() updates = %#v, want %#v", updates, want)
 	}
 
 	// A second run has nothing to report.
 	output.Reset()
-	if err := updateGoWorkVersions(&output, ".", "1.27", config.Default().Scan, false); err != nil {
-		t.Fatalf("updateGoWorkVersions() error: %v", err)
+	updates, err = updateGoWorkFiles(&output, ".", &Options{GoVersion: "1.27"}, config.Default().Scan, false)
+	if err != nil {
+		t.Fatalf("updateGoWorkFiles() error: %v", err)
 	}
-	if got := output.String(); got != "" {
-		t.Fatalf("updateGoWorkVersions() = %q, want no output", got)
+	if got := output.String(); got != "" || len(updates) != 0 {
+		t.Fatalf("updateGoWorkFiles() = %q, %v, want no output", got, updates)
 	}
 }
 
@@ -225,8 +231,8 @@ func TestUpdateWorkspaceGoVersion(t *testing.T) {
 	chdir(t, root)
 
 	var output bytes.Buffer
-	if err := updateGoWorkVersions(&output, ".", "1.25", config.Default().Scan, false); err != nil {
-		t.Fatalf("updateGoWorkVersions() error: %v", err)
+	if _, err := updateGoWorkFiles(&output, ".", &Options{GoVersion: "1.25"}, config.Default().Scan, false); err != nil {
+		t.Fatalf("updateGoWorkFiles() error: %v", err)
 	}
 	modPaths := map[string]string{"example.com/app": "./app", "example.com/lib": "./lib"}
 	updateDeps(&output, modPaths, nil, &Options{GoVersion: "1.25"}, false)
@@ -234,7 +240,7 @@ func TestUpdateWorkspaceGoVersion(t *testing.T) {
 	got := output.String()
 	for _, want := range []string{
 		"./go.work: go 1.24 → 1.25\n",
-		"| ./app | example.com/app | go 1.24 → 1.25 |",
+		"| ./app | example.com/app | go 1.24 → 1.25<br>- toolchain go1.24.1 |",
 		"| ./lib | example.com/lib | go 1.24 → 1.25 |",
 	} {
 		if !strings.Contains(got, want) {
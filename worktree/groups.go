@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/titpetric/tools/worktree/components"
+	"github.com/titpetric/tools/worktree/config"
+)
+
+// projectModule returns the module path of a project, or for a git
+// repository without a go.mod, its folder as the name it is listed under.
+func projectModule(project projectDir) (string, error) {
+	if !project.GoModule {
+		return filepath.ToSlash(strings.TrimPrefix(project.Path, "./")), nil
+	}
+	return readModulePath(project.Path)
+}
+
+// groupProjects returns the projects whose module the named group holds.
+func groupProjects(projects []projectDir, groups config.Groups, name string) ([]projectDir, error) {
+	if _, ok := groups[name]; !ok {
+		if len(groups) == 0 {
+			return nil, fmt.Errorf("unknown group @%s, the configuration defines no groups", name)
+		}
+		return nil, fmt.Errorf("unknown group @%s, want one of @%s", name, strings.Join(groups.Names(), ", @"))
+	}
+	var matched []projectDir
+	for _, project := range projects {
+		module, err := projectModule(project)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read module in %s: %w", project.Path, err)
+		}
+		if groups.Contains(name, module) {
+			matched = append(matched, project)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no module found in group @%s", name)
+	}
+	return matched, nil
+}
+
+// diagramGroup returns the container a module is drawn in and its label
+// there. A module in a configured group is drawn in the group's container,
+// labelled with its path below the group's pattern; any other module is
+// grouped by the folder of its short path, labelled with the last segment.
+func diagramGroup(module string, groups config.Groups) (pkg, name string) {
+	short := components.ShortPath(module)
+	if group, ok := groups.Match(module); ok {
+		for _, pattern := range groups[group] {
+			if prefix, ok := strings.CutSuffix(pattern, "/..."); ok && strings.HasPrefix(module, prefix+"/") {
+				return group, strings.TrimPrefix(module, prefix+"/")
+			}
+		}
+		return group, short[strings.LastIndex(short, "/")+1:]
+	}
+	idx := strings.LastIndex(short, "/")
+	if idx == -1 {
+		return "local", short
+	}
+	return short[:idx], short[idx+1:]
+}
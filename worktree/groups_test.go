@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/titpetric/tools/worktree/config"
+)
+
+var testGroups = config.Groups{
+	"platform": {"github.com/acme/platform/..."},
+	"shop":     {"github.com/acme/shop"},
+}
+
+func TestParseOptionsGroup(t *testing.T) {
+	originalArgs := os.Args
+	originalFlags := flag.CommandLine
+	t.Cleanup(func() {
+		os.Args = originalArgs
+		flag.CommandLine = originalFlags
+	})
+
+	os.Args = []string{"worktree", "@platform", "-u"}
+	flag.CommandLine = flag.NewFlagSet("worktree", flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseOptions()
+	if opts.Group != "platform" || !opts.Update {
+		t.Fatalf("ParseOptions() = %#v, want group platform with -u", opts)
+	}
+	if opts.FilterArg != "" || opts.FilterPath != "" {
+		t.Fatalf("ParseOptions() treated the group as a path filter: %#v", opts)
+	}
+}
+
+func TestGroupProjects(t *testing.T) {
+	root := t.TempDir()
+	for dir, module := range map[string]string{
+		"auth":  "github.com/acme/platform/auth",
+		"core":  "github.com/acme/platform",
+		"shop":  "github.com/acme/shop",
+		"other": "github.com/other/lib",
+	} {
+		writeTestFile(t, filepath.Join(root, dir, "go.mod"), "module "+module+"\n")
+	}
+	var projects []projectDir
+	for _, dir := range []string{"auth", "core", "other", "shop"} {
+		projects = append(projects, projectDir{Path: filepath.Join(root, dir), GoModule: true})
+	}
+
+	got, err := groupProjects(projects, testGroups, "platform")
+	if err != nil {
+		t.Fatalf("groupProjects() error: %v", err)
+	}
+	want := []projectDir{projects[0], projects[1]}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("groupProjects() = %v, want %v", got, want)
+	}
+
+	if _, err := groupProjects(projects, testGroups, "billing"); err == nil || !strings.Contains(err.Error(), "want one of @platform, @shop") {
+		t.Fatalf("groupProjects() with an unknown group error = %v", err)
+	}
+}
+
+func TestDiagramGroup(t *testing.T) {
+	tests := []struct {
+		module, pkg, name string
+	}{
+		{"github.com/acme/platform/auth", "platform", "auth"},
+		{"github.com/acme/platform/auth/v2", "platform", "auth/v2"},
+		{"github.com/acme/platform", "platform", "platform"},
+		{"github.com/acme/shop", "shop", "shop"},
+		{"github.com/other/lib", "other", "lib"},
+		{"tools", "local", "tools"},
+	}
+	for _, test := range tests {
+		pkg, name := diagramGroup(test.module, testGroups)
+		if pkg != test.pkg || name != test.name {
+			t.Errorf("diagramGroup(%q) = %q, %q, want %q, %q", test.module, pkg, name, test.pkg, test.name)
+		}
+	}
+}
+
+// TestDiagramsDrawGroups checks a configured group becomes one container in
+// both diagrams, holding modules from different folders.
+func TestDiagramsDrawGroups(t *testing.T) {
+	modules := []moduleInfo{
+		{Name: "github.com/acme/platform/auth", Uses: []string{"github.com/acme/platform"}},
+		{Name: "github.com/acme/platform"},
+		{Name: "github.com/acme/shop", Uses: []string{"github.com/acme/platform"}},
+	}
+
+	var d2 strings.Builder
+	renderD2(&d2, modules, testGroups)
+	for _, want := range []string{"platform: platform {", "shop: shop {", "platform.auth <- platform.platform"} {
+		if !strings.Contains(d2.String(), want) {
+			t.Errorf("renderD2() missing %q:\n%s", want, d2.String())
+		}
+	}
+
+	var puml strings.Builder
+	renderPUML(&puml, modules, testGroups)
+	for _, want := range []string{`package "platform" {`, `package "shop" {`, "note right of github_com_acme_platform : Used by shop"} {
+		if !strings.Contains(puml.String(), want) {
+			t.Errorf("renderPUML() missing %q:\n%s", want, puml.String())
+		}
+	}
+}
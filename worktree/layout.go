@@ -0,0 +1,196 @@
+package main
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// layoutMinWidth is the narrowest a column is shrunk to, unless its header
+// or its content is narrower still.
+const layoutMinWidth = 8
+
+// streamMinWidth is the narrowest the open last column of a streamTable
+// gets before the leading columns are shrunk to make room.
+const streamMinWidth = 20
+
+// ellipsis ends a truncated line.
+const ellipsis = "…"
+
+// columnDropOrder lists the table columns that are left out when even
+// shrunk to their narrowest they do not fit, the first dropped first. The
+// module column is never dropped.
+var columnDropOrder = []string{"contributors", "commit", "released", "age", "toolchain", "go", "usage", "latest", "state", "branch"}
+
+// terminalWidth returns the width tables are fitted to: --width when given,
+// else the width of the terminal w writes to. It returns 0, no limit, when
+// w is not a terminal.
+func terminalWidth(w io.Writer, opts *Options) int {
+	if opts.Width > 0 {
+		return opts.Width
+	}
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(f.Fd()) {
+		return 0
+	}
+	width, _, err := term.GetSize(f.Fd())
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// tableWidth returns the display width of a boxed table with columns of
+// the given widths: a border and a space of padding on either side of each.
+func tableWidth(widths []int) int {
+	total := 1
+	for _, width := range widths {
+		total += width + 3
+	}
+	return total
+}
+
+// minColumnWidth returns the narrowest a column of the natural width is
+// shrunk to, keeping its header whole.
+func minColumnWidth(header string, natural int) int {
+	return max(ansi.StringWidth(header), min(natural, layoutMinWidth))
+}
+
+// shrinkWidths narrows the widest columns a cell at a time, never below
+// their minimum, until the table fits limit or nothing is left to narrow.
+func shrinkWidths(widths, mins []int, limit int) []int {
+	widths = append([]int(nil), widths...)
+	for tableWidth(widths) > limit {
+		widest := -1
+		for i, width := range widths {
+			if width > mins[i] && (widest < 0 || width > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		widths[widest]--
+	}
+	return widths
+}
+
+// fitTable fits a table to limit. It drops columns in columnDropOrder while
+// the remaining ones do not fit at their narrowest, then narrows the widest
+// columns and fits each cell to its column. It returns the headers, rows and
+// widths to write.
+func fitTable(names, headers []string, rows []components.Rows, limit int) ([]string, []components.Rows, []int) {
+	naturals := headerWidths(headers)
+	for _, row := range rows {
+		for i, cell := range row {
+			naturals[i] = max(naturals[i], cell.Width())
+		}
+	}
+	if limit <= 0 || tableWidth(naturals) <= limit {
+		return headers, rows, naturals
+	}
+
+	keep := make([]bool, len(headers))
+	mins := make([]int, len(headers))
+	for i := range headers {
+		keep[i] = true
+		mins[i] = minColumnWidth(headers[i], naturals[i])
+	}
+	kept := func(values []int) []int {
+		var out []int
+		for i, value := range values {
+			if keep[i] {
+				out = append(out, value)
+			}
+		}
+		return out
+	}
+	for _, drop := range columnDropOrder {
+		if tableWidth(kept(mins)) <= limit {
+			break
+		}
+		for i, name := range names {
+			if name == drop && len(kept(mins)) > 1 {
+				keep[i] = false
+			}
+		}
+	}
+
+	widths := shrinkWidths(kept(naturals), kept(mins), limit)
+	var fitHeaders []string
+	for i, header := range headers {
+		if keep[i] {
+			fitHeaders = append(fitHeaders, header)
+		}
+	}
+	fitRows := make([]components.Rows, 0, len(rows))
+	for _, row := range rows {
+		var fitted components.Rows
+		for i, cell := range row {
+			if keep[i] {
+				fitted = append(fitted, fitCell(cell, widths[len(fitted)]))
+			}
+		}
+		fitRows = append(fitRows, fitted)
+	}
+	return fitHeaders, fitRows, widths
+}
+
+// fitCell fits the lines of a cell to width. A single line is truncated with
+// an ellipsis; the lines of a multi-line cell, such as commit messages, are
+// wrapped within the column instead.
+func fitCell(c components.Cell, width int) components.Cell {
+	if c.Width() <= width {
+		return c
+	}
+	if len(c) == 1 {
+		return components.Cell{truncateLine(c[0], width)}
+	}
+	var lines components.Cell
+	for _, line := range c {
+		lines = append(lines, wrapLine(line, width)...)
+	}
+	return lines
+}
+
+// truncateLine cuts a line to width, ending it with an ellipsis.
+func truncateLine(line string, width int) string {
+	if line == components.Separator || ansi.StringWidth(line) <= width {
+		return line
+	}
+	return ansi.Truncate(line, width, ellipsis) + components.ColorReset
+}
+
+// sgrRE matches an SGR escape sequence, which sets the color of what follows.
+var sgrRE = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// wrapLine wraps a line to width. The lines after the first of a list item
+// are indented under its text, and each carries on the color the line
+// before it left set, since the table border in between resets it.
+func wrapLine(line string, width int) []string {
+	if line == components.Separator || ansi.StringWidth(line) <= width {
+		return []string{line}
+	}
+	bullet, indent := "", ""
+	if body, ok := strings.CutPrefix(line, "- "); ok && width > 4 {
+		line, bullet, indent = body, "- ", "  "
+	}
+	parts := strings.Split(ansi.Wrap(line, width-len(indent), ""), "\n")
+	lines := []string{bullet + parts[0]}
+	carry := ""
+	for i := 1; i < len(parts); i++ {
+		if codes := sgrRE.FindAllString(parts[i-1], -1); len(codes) > 0 {
+			carry = codes[len(codes)-1]
+		}
+		if carry == components.ColorReset {
+			carry = ""
+		}
+		lines = append(lines, indent+carry+parts[i])
+	}
+	return lines
+}
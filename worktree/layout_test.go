@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/titpetric/tools/worktree/components"
+)
+
+func TestTerminalWidth(t *testing.T) {
+	if got := terminalWidth(&bytes.Buffer{}, &Options{}); got != 0 {
+		t.Fatalf("terminalWidth(buffer) = %d, want no limit", got)
+	}
+	if got := terminalWidth(&bytes.Buffer{}, &Options{Width: 80}); got != 80 {
+		t.Fatalf("terminalWidth(--width 80) = %d, want 80", got)
+	}
+}
+
+func TestFitTable(t *testing.T) {
+	names := []string{"module", "latest", "state", "contributors"}
+	headers := []string{"Module", "Latest", "Git State", "Contributors"}
+	rows := []components.Rows{{
+		{"./services/payments-gateway"},
+		{"v1.2.0"},
+		{components.ColorAmber + "Commits since release:" + components.ColorReset, "- abc1234 fix the retry of a failed capture"},
+		{"3"},
+	}}
+
+	// Wide enough: nothing changes.
+	if _, _, widths := fitTable(names, headers, rows, 200); !reflect.DeepEqual(widths, []int{27, 6, 43, 12}) {
+		t.Fatalf("fitTable(200) widths = %v, want the natural widths", widths)
+	}
+
+	// Contributors is dropped first, then the widest columns narrow: the
+	// module path is truncated and the commit message wrapped.
+	gotHeaders, gotRows, widths := fitTable(names, headers, rows, 45)
+	if want := []string{"Module", "Latest", "Git State"}; !reflect.DeepEqual(gotHeaders, want) {
+		t.Fatalf("fitTable(45) headers = %q, want %q", gotHeaders, want)
+	}
+	if got := tableWidth(widths); got > 45 {
+		t.Fatalf("fitTable(45) is %d wide: %v", got, widths)
+	}
+	var cells []string
+	for _, cell := range gotRows[0] {
+		for _, line := range cell {
+			cells = append(cells, ansi.Strip(line))
+		}
+	}
+	want := []string{
+		"./services/pa…",
+		"v1.2.0",
+		"Commits since",
+		"release:",
+		"- abc1234 fix",
+		"  the retry of",
+		"  a failed",
+		"  capture",
+	}
+	if !reflect.DeepEqual(cells, want) {
+		t.Fatalf("fitTable(45) cells = %q, want %q", cells, want)
+	}
+}
+
+func TestWrapLineCarriesColor(t *testing.T) {
+	line := "- " + components.ColorTeal + "abc1234" + components.ColorReset + " " + components.ColorWhite + "fix the retry of a failed capture" + components.ColorReset
+	got := wrapLine(line, 20)
+	if len(got) < 2 {
+		t.Fatalf("wrapLine() = %q, want it wrapped", got)
+	}
+	for _, l := range got[1:] {
+		if !bytes.HasPrefix([]byte(l), []byte("  "+components.ColorWhite)) {
+			t.Fatalf("wrapLine() continued %q without the indent and color of the message", l)
+		}
+	}
+}
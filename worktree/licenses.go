@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/module"
+
+	"github.com/titpetric/tools/worktree/components"
+	"github.com/titpetric/tools/worktree/config"
+)
+
+// commandLicenses lists the licenses of the dependencies of the workspace.
+const commandLicenses = "licenses"
+
+// The license a dependency reads as when it cannot be classified: license
+// text that is not one of the recognized licenses, no license file at all, or
+// sources that are not in the module cache.
+const (
+	licenseUnknown = "unknown"
+	licenseNone    = "none"
+	licenseMissing = "not downloaded"
+)
+
+// licenseTexts recognizes a license by phrases of its text, checked in order
+// so that the more specific licenses are tried first. Text is compared with
+// its whitespace collapsed and in upper case.
+var licenseTexts = []struct {
+	id      string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"GNU AFFERO GENERAL PUBLIC LICENSE", "VERSION 3"}},
+	{"LGPL-3.0", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "VERSION 3"}},
+	{"LGPL-2.1", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "VERSION 2.1"}},
+	{"GPL-3.0", []string{"GNU GENERAL PUBLIC LICENSE", "VERSION 3"}},
+	{"GPL-2.0", []string{"GNU GENERAL PUBLIC LICENSE", "VERSION 2"}},
+	{"MPL-2.0", []string{"MOZILLA PUBLIC LICENSE", "VERSION 2.0"}},
+	{"Apache-2.0", []string{"APACHE LICENSE", "VERSION 2.0"}},
+	{"BSD-3-Clause", []string{"REDISTRIBUTION AND USE IN SOURCE AND BINARY FORMS", "NEITHER THE NAME"}},
+	{"BSD-3-Clause", []string{"REDISTRIBUTION AND USE IN SOURCE AND BINARY FORMS", "THE NAMES OF ITS CONTRIBUTORS MAY NOT BE USED"}},
+	{"BSD-2-Clause", []string{"REDISTRIBUTION AND USE IN SOURCE AND BINARY FORMS"}},
+	{"MIT", []string{"PERMISSION IS HEREBY GRANTED, FREE OF CHARGE"}},
+	{"ISC", []string{"PERMISSION TO USE, COPY, MODIFY, AND/OR DISTRIBUTE THIS SOFTWARE"}},
+}
+
+// classifyLicense names the license a license file holds, or licenseUnknown.
+func classifyLicense(text string) string {
+	text = strings.ToUpper(strings.Join(strings.Fields(text), " "))
+	for _, license := range licenseTexts {
+		matched := true
+		for _, phrase := range license.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return license.id
+		}
+	}
+	return licenseUnknown
+}
+
+// isLicenseFile reports whether a file name is one a module keeps its
+// license in: LICENSE, LICENCE or COPYING, with any suffix, such as
+// LICENSE.md or LICENSE-APACHE.
+func isLicenseFile(name string) bool {
+	name = strings.ToUpper(name)
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLicense classifies the license files at the root of the module
+// sources in dir. A module with several, such as a dual license, reads as
+// each of them joined with " OR ".
+func sourceLicense(dir string) string {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return licenseMissing
+	}
+	var ids []string
+	for _, file := range files {
+		if file.IsDir() || !isLicenseFile(file.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		if id := classifyLicense(string(data)); !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return licenseNone
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, " OR ")
+}
+
+// licenseIDs splits a license as sourceLicense names it into its licenses.
+func licenseIDs(license string) []string {
+	return strings.Split(license, " OR ")
+}
+
+// modCacheDir returns the module cache the go tool downloads sources to.
+func modCacheDir() (string, error) {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir, nil
+	}
+	out, err := exec.Command("go", "env", "GOMODCACHE").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the module cache: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// buildModule is a module in the build list of a workspace module, with the
+// directory its sources are read from.
+type buildModule struct {
+	path    string
+	version string
+	dir     string
+}
+
+// buildListFormat prints every module of the build list but the main module,
+// with the module replacing it, if any.
+const buildListFormat = "{{if not .Main}}{{.Path}}\t{{.Version}}{{with .Replace}}\t{{.Path}}\t{{.Version}}{{end}}{{end}}"
+
+// buildList returns the build list of the module in dir, the modules its
+// packages are built from, with their sources in cache. It asks the go tool
+// without a network or the workspace file, so the list is the module's own,
+// and read-only, so go.sum is left as it is; the user's own GOFLAGS, from the
+// environment or go env -w, still apply. When the go tool cannot resolve the
+// list that way, the requirements of go.mod and go.sum stand in for it.
+func buildList(dir, cache string) ([]buildModule, error) {
+	goflags := strings.TrimSpace(firstCommandLine(dir, "go", "env", "GOFLAGS") + " -mod=readonly")
+	cmd := exec.Command("go", "list", "-m", "-f", buildListFormat, "all")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOPROXY=off", "GOFLAGS="+goflags)
+	out, err := cmd.Output()
+	if err != nil {
+		reqs, _, err := moduleRequirements(dir)
+		if err != nil {
+			return nil, err
+		}
+		var list []buildModule
+		for path, version := range reqs {
+			list = append(list, buildModule{path: path, version: version, dir: cachedSources(cache, path, version)})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].path < list[j].path })
+		return list, nil
+	}
+
+	var list []buildModule
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		m := buildModule{path: fields[0], version: fields[1], dir: cachedSources(cache, fields[0], fields[1])}
+		if len(fields) == 4 {
+			m.dir = cachedSources(cache, fields[2], fields[3])
+			if fields[3] == "" {
+				m.dir = fields[2]
+				if !filepath.IsAbs(m.dir) {
+					m.dir = filepath.Join(dir, m.dir)
+				}
+			}
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+// cachedSources returns where the module cache keeps the sources of a module
+// version, with upper case letters escaped as the go tool escapes them.
+func cachedSources(cache, path, version string) string {
+	escapedPath, err := module.EscapePath(path)
+	if err != nil {
+		return ""
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cache, filepath.FromSlash(escapedPath)+"@"+escapedVersion)
+}
+
+// licenseEntry is a dependency of the workspace with its license.
+type licenseEntry struct {
+	Path    string `json:"dependency"`
+	Version string `json:"version"`
+	License string `json:"license"`
+	Denied  bool   `json:"denied"`
+
+	// UsedBy lists the workspace modules building with the dependency.
+	UsedBy []string `json:"used_by"`
+}
+
+// collectLicenses classifies the dependencies in the build list of every
+// workspace module, returning each module's dependencies sorted by path.
+// Workspace modules are not dependencies of each other here; their licenses
+// are the workspace's own.
+func collectLicenses(modPaths map[string]string, cache string, deny config.Licenses) (map[string][]licenseEntry, error) {
+	licenses := make(map[string]string)
+	inventory := make(map[string][]licenseEntry)
+	for modPath, dir := range modPaths {
+		list, err := buildList(dir, cache)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the build list of %s: %w", modPath, err)
+		}
+		for _, m := range list {
+			if _, internal := modPaths[m.path]; internal {
+				continue
+			}
+			license, ok := licenses[m.dir]
+			if !ok {
+				license = sourceLicense(m.dir)
+				licenses[m.dir] = license
+			}
+			inventory[modPath] = append(inventory[modPath], licenseEntry{
+				Path:    m.path,
+				Version: m.version,
+				License: license,
+				Denied:  licenseDenied(license, deny),
+			})
+		}
+		sort.Slice(inventory[modPath], func(i, j int) bool { return inventory[modPath][i].Path < inventory[modPath][j].Path })
+	}
+	return inventory, nil
+}
+
+// licenseDenied reports whether a license is denied. A dual license is only
+// denied when each of its choices is.
+func licenseDenied(license string, deny config.Licenses) bool {
+	ids := licenseIDs(license)
+	for _, id := range ids {
+		if !deny.Denied(id) {
+			return false
+		}
+	}
+	return len(ids) > 0
+}
+
+// combineLicenses merges the inventories of the workspace modules into one
+// entry per dependency version, listing the modules using it, sorted by
+// dependency and version.
+func combineLicenses(inventory map[string][]licenseEntry) []licenseEntry {
+	byVersion := make(map[string]*licenseEntry)
+	for modPath, entries := range inventory {
+		for _, e := range entries {
+			key := e.Path + "@" + e.Version
+			if byVersion[key] == nil {
+				entry := e
+				entry.UsedBy = nil
+				byVersion[key] = &entry
+			}
+			byVersion[key].UsedBy = append(byVersion[key].UsedBy, modPath)
+		}
+	}
+	combined := make([]licenseEntry, 0, len(byVersion))
+	for _, e := range byVersion {
+		sort.Strings(e.UsedBy)
+		combined = append(combined, *e)
+	}
+	sort.Slice(combined, func(i, j int) bool {
+		if combined[i].Path != combined[j].Path {
+			return combined[i].Path < combined[j].Path
+		}
+		return combined[i].Version < combined[j].Version
+	})
+	return combined
+}
+
+// renderLicenses summarizes the licenses of each workspace module, counting
+// its dependencies per license, and lists the dependencies needing a look:
+// denied licenses in red, the ones that could not be classified in amber.
+func renderLicenses(w io.Writer, inventory map[string][]licenseEntry, modPaths map[string]string, styled bool) {
+	modules := make([]string, 0, len(inventory))
+	for modPath := range inventory {
+		modules = append(modules, modPath)
+	}
+	sort.Strings(modules)
+
+	denied := 0
+	var rows [][]string
+	for _, modPath := range modules {
+		entries := inventory[modPath]
+		if len(entries) == 0 {
+			continue
+		}
+		counts := make(map[string]int)
+		var flagged []string
+		for _, e := range entries {
+			counts[e.License]++
+			switch {
+			case e.Denied:
+				flagged = append(flagged, colorLines(e.Path+" "+e.License, components.ColorRed, styled))
+				denied++
+			case unclassified(e.License):
+				flagged = append(flagged, colorLines(e.Path+" "+e.License, components.ColorAmber, styled))
+			}
+		}
+		rows = append(rows, []string{
+			relPath(modPaths[modPath]),
+			components.ShortPath(modPath),
+			strings.Join(licenseCounts(counts), "\n"),
+			strings.Join(flagged, "\n"),
+		})
+	}
+	if len(rows) == 0 {
+		fmt.Fprintf(w, "No dependencies outside the workspace in %d modules.\n", len(modPaths))
+		return
+	}
+	writeSimpleTable(w, []string{"Path", "Module", "Licenses", "Flagged"}, rows, styled)
+
+	borderColor, yellow, reset := "", "", ""
+	if styled {
+		borderColor, yellow, reset = components.ColorBorder, components.ColorYellow, components.ColorReset
+	}
+	if denied > 0 {
+		fmt.Fprintf(w, "%s%d dependencies are under a denied license%s\n", borderColor, denied, reset)
+	}
+	fmt.Fprintf(w, "%srun with %s--format csv%s %sor %s--format json%s %sfor the combined inventory%s\n",
+		borderColor, yellow, reset, borderColor, yellow, reset, borderColor, reset)
+}
+
+// unclassified reports whether a license needs a look by hand.
+func unclassified(license string) bool {
+	for _, id := range licenseIDs(license) {
+		if id == licenseUnknown || id == licenseNone || id == licenseMissing {
+			return true
+		}
+	}
+	return false
+}
+
+// licenseCounts lists how many dependencies each license covers, the most
+// used first, as "MIT ×12".
+func licenseCounts(counts map[string]int) []string {
+	licenses := make([]string, 0, len(counts))
+	for license := range counts {
+		licenses = append(licenses, license)
+	}
+	sort.Slice(licenses, func(i, j int) bool {
+		if counts[licenses[i]] != counts[licenses[j]] {
+			return counts[licenses[i]] > counts[licenses[j]]
+		}
+		return licenses[i] < licenses[j]
+	})
+	lines := make([]string, len(licenses))
+	for i, license := range licenses {
+		lines[i] = fmt.Sprintf("%s ×%d", license, counts[license])
+	}
+	return lines
+}
+
+// writeLicenses writes the combined inventory as CSV or JSON, one record
+// per dependency version. In CSV the modules using a dependency are
+// separated by spaces.
+func writeLicenses(w io.Writer, entries []licenseEntry, format string) error {
+	switch format {
+	case formatJSON:
+		if entries == nil {
+			entries = []licenseEntry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case formatCSV:
+		var buf bytes.Buffer
+		out := csv.NewWriter(&buf)
+		out.Write([]string{"dependency", "version", "license", "denied", "used_by"})
+		for _, e := range entries {
+			out.Write([]string{e.Path, e.Version, e.License, fmt.Sprint(e.Denied), strings.Join(e.UsedBy, " ")})
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	return errors.New("licenses: --format takes csv or json")
+}
+
+// anyDenied reports whether an inventory holds a denied license.
+func anyDenied(entries []licenseEntry) bool {
+	for _, e := range entries {
+		if e.Denied {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/titpetric/tools/worktree/config"
+)
+
+const (
+	mitText = `MIT License
+
+Copyright (c) 2024 Example
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal`
+
+	apacheText = `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/`
+
+	bsd3Text = `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+   * Neither the name of Google Inc. nor the names of its
+contributors may be used to endorse or promote products derived from`
+
+	gplText = `                    GNU GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007`
+)
+
+func TestClassifyLicense(t *testing.T) {
+	tests := map[string]string{
+		mitText:    "MIT",
+		apacheText: "Apache-2.0",
+		bsd3Text:   "BSD-3-Clause",
+		gplText:    "GPL-3.0",
+		"Redistribution and use in source and binary forms, with or without modification": "BSD-2-Clause",
+		"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 2.1, February 1999":                   "LGPL-2.1",
+		"All rights reserved.": licenseUnknown,
+	}
+	for text, want := range tests {
+		if got := classifyLicense(text); got != want {
+			t.Errorf("classifyLicense(%.30q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestIsLicenseFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"LICENSE":        true,
+		"license.md":     true,
+		"LICENSE-APACHE": true,
+		"Licence.txt":    true,
+		"COPYING":        true,
+		"README.md":      false,
+		"go.mod":         false,
+	} {
+		if got := isLicenseFile(name); got != want {
+			t.Errorf("isLicenseFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCachedSources(t *testing.T) {
+	got := cachedSources("/cache", "github.com/BurntSushi/toml", "v1.4.0")
+	if want := filepath.Join("/cache", "github.com", "!burnt!sushi", "toml@v1.4.0"); got != want {
+		t.Fatalf("cachedSources() = %q, want %q", got, want)
+	}
+}
+
+// licenseWorkspace writes a module cache with an MIT, a GPL, a dual licensed
+// and an unlicensed module, and two workspace modules requiring them; one of
+// them also requires the other and a module missing from the cache.
+func licenseWorkspace(t *testing.T) (map[string]string, string) {
+	t.Helper()
+	cache := t.TempDir()
+	writeTestFile(t, filepath.Join(cache, "example.com", "mit@v1.0.0", "LICENSE"), mitText)
+	writeTestFile(t, filepath.Join(cache, "example.com", "!g!p!l@v1.2.0", "COPYING"), gplText)
+	writeTestFile(t, filepath.Join(cache, "example.com", "dual@v0.3.0", "LICENSE-APACHE"), apacheText)
+	writeTestFile(t, filepath.Join(cache, "example.com", "dual@v0.3.0", "LICENSE-MIT"), mitText)
+	writeTestFile(t, filepath.Join(cache, "example.com", "bare@v0.1.0", "go.mod"), "module example.com/bare\n")
+	t.Setenv("GOMODCACHE", cache)
+
+	root := t.TempDir()
+	api := filepath.Join(root, "api")
+	writeTestFile(t, filepath.Join(api, "go.mod"), "module example.com/api\n\ngo 1.25\n\nrequire (\n"+
+		"\texample.com/mit v1.0.0\n\texample.com/GPL v1.2.0\n\texample.com/core v1.0.0\n\texample.com/gone v1.0.0\n)\n")
+	core := filepath.Join(root, "core")
+	writeTestFile(t, filepath.Join(core, "go.mod"), "module example.com/core\n\ngo 1.25\n\nrequire (\n"+
+		"\texample.com/mit v1.0.0\n\texample.com/dual v0.3.0\n\texample.com/bare v0.1.0\n)\n")
+	return map[string]string{"example.com/api": api, "example.com/core": core}, cache
+}
+
+func TestCollectLicenses(t *testing.T) {
+	modPaths, cache := licenseWorkspace(t)
+	inventory, err := collectLicenses(modPaths, cache, config.Licenses{Deny: []string{"GPL", "Apache-2.0"}})
+	if err != nil {
+		t.Fatalf("collectLicenses() error: %v", err)
+	}
+	want := map[string][]licenseEntry{
+		"example.com/api": {
+			{Path: "example.com/GPL", Version: "v1.2.0", License: "GPL-3.0", Denied: true},
+			{Path: "example.com/gone", Version: "v1.0.0", License: licenseMissing},
+			{Path: "example.com/mit", Version: "v1.0.0", License: "MIT"},
+		},
+		"example.com/core": {
+			{Path: "example.com/bare", Version: "v0.1.0", License: licenseNone},
+			// Apache-2.0 is denied, but the module may be taken under MIT.
+			{Path: "example.com/dual", Version: "v0.3.0", License: "Apache-2.0 OR MIT"},
+			{Path: "example.com/mit", Version: "v1.0.0", License: "MIT"},
+		},
+	}
+	if !reflect.DeepEqual(inventory, want) {
+		t.Fatalf("collectLicenses() =\n%#v\nwant\n%#v", inventory, want)
+	}
+
+	combined := combineLicenses(inventory)
+	if len(combined) != 5 {
+		t.Fatalf("combineLicenses() = %d entries, want 5: %#v", len(combined), combined)
+	}
+	if mit := combined[4]; mit.Path != "example.com/mit" || !reflect.DeepEqual(mit.UsedBy, []string{"example.com/api", "example.com/core"}) {
+		t.Fatalf("combineLicenses() mit = %#v, want it used by both modules", mit)
+	}
+	if !anyDenied(combined) {
+		t.Fatal("anyDenied() = false, want the GPL module denied")
+	}
+}
+
+func TestRenderLicenses(t *testing.T) {
+	modPaths, cache := licenseWorkspace(t)
+	inventory, err := collectLicenses(modPaths, cache, config.Licenses{Deny: []string{"GPL"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	renderLicenses(&output, inventory, modPaths, false)
+	got := output.String()
+	for _, want := range []string{
+		"| Path | Module | Licenses | Flagged |",
+		"| example.com/api | GPL-3.0 ×1<br>MIT ×1<br>not downloaded ×1 | example.com/GPL GPL-3.0<br>example.com/gone not downloaded |",
+		"| example.com/core | Apache-2.0 OR MIT ×1<br>MIT ×1<br>none ×1 | example.com/bare none |",
+		"1 dependencies are under a denied license",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("renderLicenses() =\n%s\nwant it to hold:\n%s", got, want)
+		}
+	}
+}
+
+func TestWriteLicenses(t *testing.T) {
+	entries := []licenseEntry{
+		{Path: "example.com/GPL", Version: "v1.2.0", License: "GPL-3.0", Denied: true, UsedBy: []string{"example.com/api"}},
+		{Path: "example.com/mit", Version: "v1.0.0", License: "MIT", UsedBy: []string{"example.com/api", "example.com/core"}},
+	}
+
+	var output bytes.Buffer
+	if err := writeLicenses(&output, entries, formatCSV); err != nil {
+		t.Fatal(err)
+	}
+	want := "dependency,version,license,denied,used_by\n" +
+		"example.com/GPL,v1.2.0,GPL-3.0,true,example.com/api\n" +
+		"example.com/mit,v1.0.0,MIT,false,example.com/api example.com/core\n"
+	if got := output.String(); got != want {
+		t.Fatalf("writeLicenses(csv) =\n%s\nwant:\n%s", got, want)
+	}
+
+	output.Reset()
+	if err := writeLicenses(&output, entries, formatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []licenseEntry
+	if err := json.Unmarshal(output.Bytes(), &decoded); err != nil || !reflect.DeepEqual(decoded, entries) {
+		t.Fatalf("writeLicenses(json) = %s, %v, want the entries back", output.String(), err)
+	}
+	if !strings.Contains(output.String(), `"used_by"`) {
+		t.Fatalf("writeLicenses(json) = %s, want snake_case keys", output.String())
+	}
+
+	if err := writeLicenses(&output, entries, "xml"); err == nil {
+		t.Fatal("writeLicenses(xml) succeeded")
+	}
+}
+
+func TestParseOptionsLicensesStrict(t *testing.T) {
+	originalArgs := os.Args
+	originalFlags := flag.CommandLine
+	defer func() {
+		os.Args = originalArgs
+		flag.CommandLine = originalFlags
+	}()
+	os.Args = []string{"worktree", "licenses", "--strict"}
+	flag.CommandLine = flag.NewFlagSet("worktree", flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseOptions()
+	if !opts.Licenses || !opts.Strict {
+		t.Fatalf("ParseOptions() = %#v, want a strict license inventory", opts)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/titpetric/tools/worktree/components"
 	"github.com/titpetric/tools/worktree/config"
@@ -137,7 +138,26 @@ func main() {
 	// The setup screen runs before the configuration is read for the scan,
 	// so a document that fails to parse can still be fixed from it.
 	if opts.Configure {
-		if err := config.Run(os.Stdout); err != nil {
+		// The workspace layer lives at the scan root, found with the user
+		// document's root markers, or the built-in ones when it is broken.
+		cfg, err := config.Load()
+		if err != nil {
+			cfg = config.Default()
+		}
+		if err := applyColor(opts, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		root, err := findScanRoot(".", cfg.Scan.RootMarkers)
+		if err != nil {
+			log.Fatalf("failed to find scan root: %v", err)
+		}
+		if len(opts.ConfigArgs) > 0 {
+			if err := config.Command(os.Stdout, root, opts.Workspace, opts.ConfigArgs); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		if err := config.Run(os.Stdout, root, opts.Workspace); err != nil {
 			log.Fatal(err)
 		}
 		return
@@ -150,25 +170,91 @@ func main() {
 		if err != nil {
 			log.Fatalf("failed to list git tags: %v", err)
 		}
-		lines, err := releaseCommands(tags, opts.Release)
+		prefix, versions := moduleTags(".", tags)
+		lines, err := releaseCommands(versions, prefix, opts.Release)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, line := range lines {
+			fmt.Fprintln(os.Stdout, line)
+		}
+		return
+	}
+
+	// A retraction is added to the go.mod of the current directory and
+	// published with the next patch release of its git repository.
+	if opts.Retract != "" {
+		vi, err := parseRetraction(opts.Retract)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tags, err := gitTags(".")
+		if err != nil {
+			log.Fatalf("failed to list git tags: %v", err)
+		}
+		prefix, versions := moduleTags(".", tags)
+		lines, err := retractCommands(versions, prefix, vi)
 		if err != nil {
 			log.Fatal(err)
 		}
+		added, err := addRetraction(".", vi, opts.Reason)
+		if err != nil {
+			log.Fatalf("failed to retract %s: %v", formatRetraction(vi), err)
+		}
+		if !added {
+			fmt.Fprintf(os.Stderr, "go.mod already retracts %s\n", formatRetraction(vi))
+			return
+		}
 		for _, line := range lines {
 			fmt.Fprintln(os.Stdout, line)
 		}
 		return
 	}
 
+	// The environment is the highest layer. It applies before the scan root
+	// is searched for, so it can set the root markers, and again over the
+	// workspace layer, so it wins there too.
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("failed to load configuration: %v", err)
 	}
+	if err := config.ApplyEnv(cfg); err != nil {
+		log.Fatalf("failed to apply environment: %v", err)
+	}
 
 	root, err := findScanRoot(".", cfg.Scan.RootMarkers)
 	if err != nil {
 		log.Fatalf("failed to find scan root: %v", err)
 	}
+	cfg, _, err = config.Overlay(cfg, config.WorkspacePath(root))
+	if err != nil {
+		log.Fatalf("failed to load workspace configuration: %v", err)
+	}
+	if err := config.ApplyEnv(cfg); err != nil {
+		log.Fatalf("failed to apply environment: %v", err)
+	}
+	opts.ApplyDisplay(cfg.Display)
+	if err := applyColor(opts, cfg); err != nil {
+		log.Fatalf("failed to set colors: %v", err)
+	}
+
+	// A diff of two saved snapshots needs no scan of the workspace.
+	if opts.Snapshot == snapshotDiff && len(opts.SnapshotArgs) > 1 && opts.SnapshotArgs[1] != snapshotCurrent {
+		if err := runSnapshot(os.Stdout, root, opts, nil, supportsANSI(os.Stdout)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// The --where expression is compiled up front, so a typo is reported
+	// before the workspace is scanned.
+	var where wherePredicate
+	if opts.Where != "" {
+		where, err = parseWhere(opts.Where, cfg.Queries)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 	if err := os.Chdir(root); err != nil {
 		log.Fatalf("failed to chdir to %s: %v", root, err)
 	}
@@ -180,12 +266,47 @@ func main() {
 		log.Fatalf("no go.work, go.mod, or .git directory found")
 	}
 
+	// A group narrows the run to its projects, so everything below, from
+	// --pull to -u, only sees them.
+	if opts.Group != "" {
+		projects, err = groupProjects(projects, cfg.Groups, opts.Group)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if opts.Pull {
-		var dirs []string
-		for _, project := range projects {
-			dirs = append(dirs, project.Path)
+		pullRepos(os.Stdout, projectPaths(projects), supportsANSI(os.Stdout))
+		return
+	}
+
+	if opts.Push {
+		pushRepos(os.Stdout, projectPaths(projects), opts.DryRun, supportsANSI(os.Stdout))
+		return
+	}
+
+	if opts.Fetch {
+		failed := fetchRepos(gitRepos(projectPaths(projects)))
+		paths := make([]string, 0, len(failed))
+		for path := range failed {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			fmt.Fprintf(os.Stderr, "failed to fetch %s: %v\n", relPath(path), failed[path])
+		}
+	}
+
+	if opts.Branches {
+		var reports []branchReport
+		for _, repo := range gitRepos(projectPaths(projects)) {
+			reports = append(reports, collectBranches(repo, cfg.Scan.DefaultBranches, opts.StaleDays, time.Now()))
+		}
+		styled := supportsANSI(os.Stdout)
+		renderBranches(os.Stdout, reports, opts.StaleDays, styled)
+		if opts.Prune {
+			pruneBranches(os.Stdout, os.Stdin, reports, styled)
 		}
-		pullRepos(os.Stdout, dirs, supportsANSI(os.Stdout))
 		return
 	}
 
@@ -194,12 +315,11 @@ func main() {
 	goModPaths := make(map[string]string)
 	shortNames := make(map[string]string)
 	for _, project := range projects {
-		modPath := filepath.ToSlash(strings.TrimPrefix(project.Path, "./"))
+		modPath, err := projectModule(project)
+		if err != nil {
+			log.Fatalf("failed to read module in %s: %v", project.Path, err)
+		}
 		if project.GoModule {
-			modPath, err = readModulePath(project.Path)
-			if err != nil {
-				log.Fatalf("failed to read module in %s: %v", project.Path, err)
-			}
 			goModPaths[modPath] = project.Path
 		}
 		modPaths[modPath] = project.Path
@@ -227,6 +347,107 @@ func main() {
 		}
 	}
 
+	if opts.Deps {
+		drifts, err := collectDrift(goModPaths)
+		if err != nil {
+			log.Fatal(err)
+		}
+		styled := supportsANSI(os.Stdout)
+		if !opts.Align || len(drifts) == 0 {
+			renderDrift(os.Stdout, drifts, styled)
+			return
+		}
+		targets, lagging := alignTargets(drifts, goModPaths)
+		updates := updateDeps(os.Stdout, lagging, targets, opts, styled)
+		if opts.Commit && len(updates) > 0 {
+			commitUpdates(os.Stdout, updates, opts.Branch, styled)
+		}
+		return
+	}
+
+	if opts.Vuln {
+		dir, err := vulnDBDir(opts.VulnDB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		db, err := openVulnDB(dir)
+		if err != nil {
+			log.Fatalf("failed to open vulnerability database: %v", err)
+		}
+		findings, err := collectVulns(db, goModPaths)
+		if err != nil {
+			log.Fatal(err)
+		}
+		styled := supportsANSI(os.Stdout)
+		renderVulns(os.Stdout, findings, goModPaths, styled)
+		if !opts.Fix {
+			return
+		}
+		targets, dirs := fixTargets(findings, goModPaths)
+		if len(dirs) == 0 {
+			return
+		}
+		updates := updateDeps(os.Stdout, dirs, targets, opts, styled)
+		if opts.Commit && len(updates) > 0 {
+			commitUpdates(os.Stdout, updates, opts.Branch, styled)
+		}
+		return
+	}
+
+	if opts.Licenses {
+		if opts.Format != "" && opts.Format != formatCSV && opts.Format != formatJSON {
+			log.Fatalf("licenses: --format takes csv or json, not %q", opts.Format)
+		}
+		cache, err := modCacheDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+		inventory, err := collectLicenses(goModPaths, cache, cfg.Licenses)
+		if err != nil {
+			log.Fatal(err)
+		}
+		combined := combineLicenses(inventory)
+		if opts.Format != "" {
+			if err := writeLicenses(os.Stdout, combined, opts.Format); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			renderLicenses(os.Stdout, inventory, goModPaths, supportsANSI(os.Stdout))
+		}
+		// With --strict a denied license fails the run, so a release pipeline
+		// stops on it.
+		if opts.Strict && anyDenied(combined) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.At != "" {
+		var mods []string
+		for mod := range goModPaths {
+			mods = append(mods, mod)
+		}
+		sort.Strings(mods)
+		if opts.FilterPath != "" {
+			mods = filterModules(mods, goModPaths, shortNames, opts)
+		}
+		renderAt(os.Stdout, collectAt(mods, goModPaths, opts.At, cfg.Scan.DefaultBranches), supportsANSI(os.Stdout))
+		return
+	}
+
+	if opts.Changelog {
+		var mods []string
+		for mod := range goModPaths {
+			mods = append(mods, mod)
+		}
+		sort.Strings(mods)
+		if opts.FilterPath != "" {
+			mods = filterModules(mods, goModPaths, shortNames, opts)
+		}
+		renderChangelogs(os.Stdout, mods, goModPaths, supportsANSI(os.Stdout))
+		return
+	}
+
 	// Build reverse map (used_by)
 	usedBy := make(map[string][]string)
 	for mod, deps := range uses {
@@ -235,71 +456,36 @@ func main() {
 		}
 	}
 
-	// Get latest git tag for each module
+	// Get latest git tag for each module, read with the prefix of its
+	// subdirectory in a multi-module repository
 	latestTags := make(latestTags)
+	tagPrefixes := make(map[string]string)
 	for modPath, dir := range modPaths {
-		tag := latestGitTag(dir)
+		tagPrefixes[modPath] = gitTagPrefix(dir, modPath)
+		tag := latestGitTag(dir, tagPrefixes[modPath], pathMajor(modPath))
 		if tag != "" {
 			latestTags[modPath] = tag
 		}
 	}
 
-	// Build sorted output: order by count(used_by) desc, count(uses) asc, name asc
+	// The modules are sorted by name here, and in display order once their
+	// info is built
 	var sortedMods []string
 	for mod := range modPaths {
 		sortedMods = append(sortedMods, mod)
 	}
-	sort.Slice(sortedMods, func(i, j int) bool {
-		ubi, ubj := len(usedBy[sortedMods[i]]), len(usedBy[sortedMods[j]])
-		if ubi != ubj {
-			return ubi > ubj
-		}
-		ui, uj := len(uses[sortedMods[i]]), len(uses[sortedMods[j]])
-		if ui != uj {
-			return ui < uj
-		}
-		return sortedMods[i] < sortedMods[j]
-	})
+	sort.Strings(sortedMods)
 
 	// Filter modules if a path argument was given
 	if opts.FilterPath != "" {
-		var matched []string
-
-		// Exact short name match
-		if mod, ok := shortNames[opts.FilterArg]; ok {
-			matched = append(matched, mod)
-		}
-
-		// Path-based match
-		if len(matched) == 0 {
-			workRoot, _ := os.Getwd()
-			for _, mod := range sortedMods {
-				dir := modPaths[mod]
-				absDir := filepath.Join(workRoot, dir)
-				if isSubpath(absDir, opts.FilterPath) || isSubpath(opts.FilterPath, absDir) {
-					matched = append(matched, mod)
-				}
-			}
-		}
-
-		// Substring match against dir or module name
-		if len(matched) == 0 {
-			for _, mod := range sortedMods {
-				dir := modPaths[mod]
-				if strings.Contains(dir, opts.FilterArg) || strings.Contains(mod, opts.FilterArg) {
-					matched = append(matched, mod)
-				}
-			}
-		}
-
-		if len(matched) == 0 {
-			log.Fatalf("no module found matching %s", opts.FilterArg)
-		}
-		sortedMods = matched
+		sortedMods = filterModules(sortedMods, modPaths, shortNames, opts)
 	}
 
-	// Build module info list
+	// Build module info list. The modules of a repository share how its
+	// checked out branch stands against the default branch.
 	var modules []moduleInfo
+	trunks := make(map[string]trunkState)
+	withActivity := readsActivity(opts)
 	for _, mod := range sortedMods {
 		dir := modPaths[mod]
 
@@ -308,6 +494,9 @@ func main() {
 			Path:        dir,
 			Description: readReadmeTitle(dir),
 			GoVersion:   readGoVersion(dir),
+			Toolchain:   readToolchain(dir),
+			Retractions: readRetractions(dir),
+			TagPrefix:   tagPrefixes[mod],
 		}
 
 		if tag, ok := latestTags[mod]; ok {
@@ -323,63 +512,187 @@ func main() {
 			info.UsedBy = revs
 		}
 
-		// Build git state
+		// Build git state, counting from the tag the latest version is
+		// released with
+		tag := ""
+		if info.Latest != "" {
+			tag = info.TagPrefix + info.Latest
+		}
+		// Commits to the nested modules of dir are theirs.
+		spec := modulePathspec(dir)
 		g := &components.Git{
 			BranchName: getGitBranch(dir),
 			LatestTag:  info.Latest,
 		}
 		if info.Latest != "" {
-			g.Ahead = commitsSinceTag(dir, info.Latest)
+			g.Ahead = commitsSinceTag(dir, spec, tag)
 		}
 		if st := getGitStatus(dir); st != nil {
 			g.Unpushed = st.Unpushed
+			g.Behind = st.Behind
 			g.DiffLines = st.DiffLines
 		}
+		if root, err := gitTopLevel(dir); err == nil {
+			trunk, ok := trunks[root]
+			if !ok {
+				trunk = readTrunk(root, cfg.Scan.DefaultBranches)
+				trunks[root] = trunk
+			}
+			g.DefaultBranch, g.TrunkAhead, g.TrunkBehind, g.TrunkMerged = trunk.name, trunk.ahead, trunk.behind, trunk.merged
+		}
 		if g.Ahead > 0 {
-			g.Msgs = commitMessagesSinceTag(dir, info.Latest)
+			g.Msgs = commitMessagesSinceTag(dir, spec, tag)
 		}
 		g.UntrackedFiles = getUntrackedFiles(dir)
 		if opts.Verbose {
 			g.Issues = getGitHubIssues(dir)
 		}
 		info.GitState = g
+		if withActivity {
+			info.Activity = readActivity(dir, spec, tag)
+		}
 
 		// Build usage
 		info.Usage, info.Outdated = buildUsage(versionRefs, latestTags, info)
 
 		modules = append(modules, info)
 	}
+	sortModules(modules, opts.Sort)
 
-	if opts.Update || opts.GoVersion != "" {
+	if opts.Snapshot != "" {
+		if err := runSnapshot(os.Stdout, root, opts, modules, supportsANSI(os.Stdout)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if opts.Update || opts.GoVersion != "" || opts.Toolchain != "" {
 		if len(goModPaths) == 0 {
 			log.Fatalf("dependency updates require a go.work or go.mod")
 		}
 		styled := supportsANSI(os.Stdout)
-		if opts.GoVersion != "" {
-			if err := updateGoWorkVersions(os.Stdout, ".", opts.GoVersion, cfg.Scan, styled); err != nil {
+		var updates []moduleUpdate
+		if opts.GoVersion != "" || opts.Toolchain != "" {
+			work, err := updateGoWorkFiles(os.Stdout, ".", opts, cfg.Scan, styled)
+			if err != nil {
 				log.Fatal(err)
 			}
+			updates = append(updates, work...)
+		}
+		updates = append(updates, updateDeps(os.Stdout, goModPaths, latestTags, opts, styled)...)
+		if opts.Commit && len(updates) > 0 {
+			commitUpdates(os.Stdout, updates, opts.Branch, styled)
 		}
-		updateDeps(os.Stdout, goModPaths, latestTags, opts, styled)
 		return
 	}
 
+	// A module selected by --where is shown whether or not it has anything
+	// to report, since the expression already said what to look for.
+	if where != nil {
+		modules = filterWhere(modules, where)
+		if len(modules) == 0 {
+			fmt.Printf("No modules match --where %s\n", opts.Where)
+			return
+		}
+		opts.All = true
+	}
+
 	if opts.PUML {
-		renderPUML(os.Stdout, modules)
+		renderPUML(os.Stdout, modules, cfg.Groups)
 		return
 	}
 
 	if opts.D2 {
-		renderD2(os.Stdout, modules)
+		renderD2(os.Stdout, modules, cfg.Groups)
 		return
 	}
 
-	if opts.Matrix {
-		renderDependencyMatrix(os.Stdout, modules, versionRefs, latestTags, supportsANSI(os.Stdout))
+	if opts.Matrix && opts.Format != "" {
+		if err := writeMatrix(os.Stdout, modules, versionRefs, latestTags, opts.Format); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if opts.Format != "" && !opts.Matrix {
+		if opts.Format != formatGFM {
+			log.Fatalf("--format takes gfm for the workspace table, not %q", opts.Format)
+		}
+		renderGFM(os.Stdout, modules, opts)
 		return
 	}
 
-	renderTables(os.Stdout, modules, opts, supportsANSI(os.Stdout))
+	// --svg renders the styled output to an image rather than the terminal.
+	var out io.Writer = os.Stdout
+	styled := supportsANSI(os.Stdout)
+	var image strings.Builder
+	if opts.SVG != "" {
+		if err := useSVGPalette(); err != nil {
+			log.Fatal(err)
+		}
+		out, styled = &image, true
+	}
+
+	if opts.Matrix {
+		renderDependencyMatrix(out, modules, versionRefs, latestTags, opts.Versions, styled)
+	} else {
+		renderTables(out, modules, opts, styled)
+	}
+
+	if opts.SVG != "" {
+		if err := writeSVGFile(opts.SVG, image.String()); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// projectPaths returns the directories of the projects.
+func projectPaths(projects []projectDir) []string {
+	paths := make([]string, 0, len(projects))
+	for _, project := range projects {
+		paths = append(paths, project.Path)
+	}
+	return paths
+}
+
+// filterModules returns the modules matching the path argument of opts: the
+// module with that short name, else the modules in or above that directory,
+// else the modules whose directory or path holds it. It exits when nothing
+// matches.
+func filterModules(mods []string, modPaths, shortNames map[string]string, opts *Options) []string {
+	var matched []string
+
+	// Exact short name match
+	if mod, ok := shortNames[opts.FilterArg]; ok && modPaths[mod] != "" {
+		matched = append(matched, mod)
+	}
+
+	// Path-based match
+	if len(matched) == 0 {
+		workRoot, _ := os.Getwd()
+		for _, mod := range mods {
+			dir := modPaths[mod]
+			absDir := filepath.Join(workRoot, dir)
+			if isSubpath(absDir, opts.FilterPath) || isSubpath(opts.FilterPath, absDir) {
+				matched = append(matched, mod)
+			}
+		}
+	}
+
+	// Substring match against dir or module name
+	if len(matched) == 0 {
+		for _, mod := range mods {
+			dir := modPaths[mod]
+			if strings.Contains(dir, opts.FilterArg) || strings.Contains(mod, opts.FilterArg) {
+				matched = append(matched, mod)
+			}
+		}
+	}
+
+	if len(matched) == 0 {
+		log.Fatalf("no module found matching %s", opts.FilterArg)
+	}
+	return matched
 }
 
 // isSubpath reports whether child is equal to or under parent.
@@ -406,25 +719,8 @@ func runCommand(cmd *exec.Cmd, verbose bool, stdout, stderr io.Writer) error {
 }
 
 func pullRepos(w io.Writer, dirs []string, styled bool) {
-	repos := make(map[string]struct{})
-	for _, dir := range dirs {
-		cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-		cmd.Dir = dir
-		out, err := cmd.Output()
-		if err != nil {
-			continue
-		}
-		repos[strings.TrimSpace(string(out))] = struct{}{}
-	}
-
-	paths := make([]string, 0, len(repos))
-	for path := range repos {
-		paths = append(paths, path)
-	}
-	sort.Strings(paths)
-
 	var rows [][]string
-	for _, path := range paths {
+	for _, path := range gitRepos(dirs) {
 		remote := firstCommandLine(path, "git", "remote", "-v")
 		branch := getGitBranch(path)
 		before := firstCommandLine(path, "git", "rev-parse", "HEAD")
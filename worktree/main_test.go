@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"io"
 	"os"
@@ -74,6 +75,30 @@ func TestParseOptionsVerbose(t *testing.T) {
 	}
 }
 
+// TestApplyDisplayKeepsFlags checks the display defaults of the configuration
+// apply, except where a flag on the command line says otherwise.
+func TestApplyDisplayKeepsFlags(t *testing.T) {
+	originalArgs := os.Args
+	originalFlags := flag.CommandLine
+	t.Cleanup(func() {
+		os.Args = originalArgs
+		flag.CommandLine = originalFlags
+	})
+
+	os.Args = []string{"worktree", "-v=false"}
+	flag.CommandLine = flag.NewFlagSet("worktree", flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseOptions()
+	opts.ApplyDisplay(config.Display{Columns: []string{"module", "usage"}, Sort: "name", ShowAll: true, Verbose: true})
+	if !opts.All || opts.Verbose {
+		t.Fatalf("ApplyDisplay() = all %v, verbose %v, want the default for --all and the flag for -v", opts.All, opts.Verbose)
+	}
+	if !reflect.DeepEqual(opts.Columns, []string{"module", "usage"}) || opts.Sort != "name" {
+		t.Fatalf("ApplyDisplay() = columns %v, sort %q", opts.Columns, opts.Sort)
+	}
+}
+
 // TestParseOptionsConfigure checks the config subcommand opens the setup
 // screen and is not mistaken for a path filter.
 func TestParseOptionsConfigure(t *testing.T) {
@@ -95,6 +120,30 @@ func TestParseOptionsConfigure(t *testing.T) {
 	if opts.FilterArg != "" || opts.FilterPath != "" {
 		t.Fatalf("ParseOptions() treated %q as a filter: %#v", commandConfig, opts)
 	}
+	if len(opts.ConfigArgs) != 0 {
+		t.Fatalf("ParseOptions() ConfigArgs = %v, want none", opts.ConfigArgs)
+	}
+}
+
+func TestParseOptionsConfigSubcommand(t *testing.T) {
+	originalArgs := os.Args
+	originalFlags := flag.CommandLine
+	t.Cleanup(func() {
+		os.Args = originalArgs
+		flag.CommandLine = originalFlags
+	})
+
+	os.Args = []string{"worktree", commandConfig, "set", "scan.enable_gitignore", "false", "--workspace"}
+	flag.CommandLine = flag.NewFlagSet("worktree", flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseOptions()
+	if !opts.Configure || !opts.Workspace {
+		t.Fatalf("ParseOptions() = %#v, want the workspace configuration", opts)
+	}
+	if want := []string{"set", "scan.enable_gitignore", "false"}; !reflect.DeepEqual(opts.ConfigArgs, want) {
+		t.Fatalf("ParseOptions() ConfigArgs = %v, want %v", opts.ConfigArgs, want)
+	}
 }
 
 func TestParseOptionsDependencyMatrix(t *testing.T) {
@@ -139,7 +188,7 @@ func TestRenderDependencyMatrix(t *testing.T) {
 		"example.com/library": "v2.0.0",
 		"example.com/service": "v1.0.0",
 	}
-	renderDependencyMatrix(&output, modules, refs, tags, true)
+	renderDependencyMatrix(&output, modules, refs, tags, false, true)
 
 	want := "" +
 		"╭──────────────┬─────────┬─────────╮\n" +
@@ -171,7 +220,7 @@ func TestRenderDependencyMatrixMinWidth(t *testing.T) {
 	}
 
 	var output bytes.Buffer
-	renderDependencyMatrix(&output, modules, nil, nil, true)
+	renderDependencyMatrix(&output, modules, nil, nil, false, true)
 
 	want := "" +
 		"╭─────────┬─────╮\n" +
@@ -192,7 +241,7 @@ func TestRenderDependencyMatrixMarkdown(t *testing.T) {
 	}
 
 	var output bytes.Buffer
-	renderDependencyMatrix(&output, modules, nil, nil, false)
+	renderDependencyMatrix(&output, modules, nil, nil, false, false)
 
 	want := "| Project | lib |\n" +
 		"| --- | --- |\n" +
@@ -203,6 +252,78 @@ func TestRenderDependencyMatrixMarkdown(t *testing.T) {
 	}
 }
 
+func TestRenderDependencyMatrixVersions(t *testing.T) {
+	modules := []moduleInfo{
+		{Name: "example.com/app", Uses: []string{"example.com/lib", "example.com/db"}},
+		{Name: "example.com/lib"},
+		{Name: "example.com/db"},
+	}
+	refs := versionRefs{"example.com/app": {"example.com/lib": "v1.2.0", "example.com/db": "v0.4.1"}}
+	tags := latestTags{"example.com/lib": "v1.3.0", "example.com/db": "v0.4.1"}
+
+	var output bytes.Buffer
+	renderDependencyMatrix(&output, modules, refs, tags, true, true)
+
+	want := "" +
+		"╭─────────┬─────────┬────────╮\n" +
+		"│ Project │ lib     │ db     │\n" +
+		"├─────────┼─────────┼────────┤\n" +
+		"│ app     │ v1.2.0* │ v0.4.1 │\n" +
+		"╰─────────┴─────────┴────────╯\n" +
+		"0 ahead, 0 with local changes, 1 deps out of date.\n"
+	if got := ansi.Strip(output.String()); got != want {
+		t.Fatalf("renderDependencyMatrix(versions) =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteMatrix(t *testing.T) {
+	modules := []moduleInfo{
+		{Name: "example.com/app", Uses: []string{"example.com/lib", "example.com/db"}},
+		{Name: "example.com/lib", Uses: []string{"example.com/db"}},
+		{Name: "example.com/db"},
+	}
+	refs := versionRefs{
+		"example.com/app": {"example.com/lib": "v1.2.0", "example.com/db": "v0.4.1"},
+		"example.com/lib": {"example.com/db": "v0.4.1"},
+	}
+	tags := latestTags{"example.com/lib": "v1.3.0", "example.com/db": "v0.4.1"}
+
+	tests := map[string]string{
+		formatCSV: "project,example.com/lib,example.com/db\n" +
+			"example.com/app,\"v1.2.0 (latest v1.3.0, outdated)\",v0.4.1 (latest)\n" +
+			"example.com/lib,,v0.4.1 (latest)\n",
+		formatTSV: "project\texample.com/lib\texample.com/db\n" +
+			"example.com/app\tv1.2.0 (latest v1.3.0, outdated)\tv0.4.1 (latest)\n" +
+			"example.com/lib\t\tv0.4.1 (latest)\n",
+	}
+	for format, want := range tests {
+		var output bytes.Buffer
+		if err := writeMatrix(&output, modules, refs, tags, format); err != nil {
+			t.Fatal(err)
+		}
+		if got := output.String(); got != want {
+			t.Errorf("writeMatrix(%s) =\n%s\nwant:\n%s", format, got, want)
+		}
+	}
+
+	var output bytes.Buffer
+	if err := writeMatrix(&output, modules, refs, tags, formatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var entries []matrixEntry
+	if err := json.Unmarshal(output.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	want := matrixEntry{Project: "example.com/app", Dependency: "example.com/lib", Version: "v1.2.0", Latest: "v1.3.0", Outdated: true}
+	if len(entries) != 3 || entries[0] != want {
+		t.Fatalf("writeMatrix(json) = %+v, want %+v first of 3", entries, want)
+	}
+
+	if err := writeMatrix(&output, modules, refs, tags, "xml"); err == nil {
+		t.Fatal("writeMatrix(xml) succeeded")
+	}
+}
+
 func TestPullReposRendersGitDetails(t *testing.T) {
 	root := t.TempDir()
 	remote := filepath.Join(root, "remote.git")
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -15,14 +18,16 @@ const dependencyMark = "▲"
 // once the single space of padding on each side is counted.
 const matrixMinWidth = 3
 
-func renderDependencyMatrix(w io.Writer, modules []moduleInfo, refs versionRefs, tags latestTags, styled bool) {
+// matrixLayout returns the rows and columns of the dependency matrix: the
+// modules requiring another workspace module or holding local changes, and
+// the workspace modules required by any.
+func matrixLayout(modules []moduleInfo) (rows, columns []moduleInfo) {
 	available := make(map[string]struct{}, len(modules))
 	for _, module := range modules {
 		available[module.Name] = struct{}{}
 	}
 
 	used := make(map[string]struct{})
-	var rows []moduleInfo
 	for _, module := range modules {
 		hasDependency := false
 		for _, dependency := range module.Uses {
@@ -36,12 +41,31 @@ func renderDependencyMatrix(w io.Writer, modules []moduleInfo, refs versionRefs,
 		}
 	}
 
-	var columns []moduleInfo
 	for _, module := range modules {
 		if _, ok := used[module.Name]; ok {
 			columns = append(columns, module)
 		}
 	}
+	return rows, columns
+}
+
+// matrixMark returns the text of the cell where a module requires a
+// dependency: the mark, or with versions the version it requires, followed
+// by a * when that is not the latest tag.
+func matrixMark(refs versionRefs, tags latestTags, dependent, dependency string, versions bool) (string, bool) {
+	mark := dependencyMark
+	if version := refs[dependent][dependency]; versions && version != "" {
+		mark = version
+	}
+	outdated := dependencyOutdated(refs, tags, dependent, dependency)
+	if outdated {
+		mark += "*"
+	}
+	return mark, outdated
+}
+
+func renderDependencyMatrix(w io.Writer, modules []moduleInfo, refs versionRefs, tags latestTags, versions, styled bool) {
+	rows, columns := matrixLayout(modules)
 	if !styled {
 		headers := []string{"Project"}
 		for _, module := range columns {
@@ -57,10 +81,7 @@ func renderDependencyMatrix(w io.Writer, modules []moduleInfo, refs versionRefs,
 			row[0] = matrixProjectLabel(module)
 			for i, candidate := range columns {
 				if _, ok := dependencies[candidate.Name]; ok {
-					row[i+1] = dependencyMark
-					if dependencyOutdated(refs, tags, module.Name, candidate.Name) {
-						row[i+1] += "*"
-					}
+					row[i+1], _ = matrixMark(refs, tags, module.Name, candidate.Name, versions)
 				}
 			}
 			values = append(values, row)
@@ -71,6 +92,28 @@ func renderDependencyMatrix(w io.Writer, modules []moduleInfo, refs versionRefs,
 		return
 	}
 
+	cells := make([][]string, len(rows))
+	for r, module := range rows {
+		dependencies := make(map[string]struct{}, len(module.Uses))
+		for _, dependency := range module.Uses {
+			dependencies[dependency] = struct{}{}
+		}
+
+		row := make([]string, len(columns)+1)
+		row[0] = matrixProjectLabel(module)
+		for i, candidate := range columns {
+			if _, ok := dependencies[candidate.Name]; ok {
+				color := components.ColorGreen
+				mark, outdated := matrixMark(refs, tags, module.Name, candidate.Name, versions)
+				if outdated {
+					color = components.ColorYellow
+				}
+				row[i+1] = color + mark + components.ColorReset
+			}
+		}
+		cells[r] = row
+	}
+
 	labels := make([]string, len(columns))
 	widths := make([]int, len(columns)+1)
 	widths[0] = ansi.StringWidth("Project")
@@ -78,8 +121,10 @@ func renderDependencyMatrix(w io.Writer, modules []moduleInfo, refs versionRefs,
 		labels[i] = components.ShortName(module.Name)
 		widths[i+1] = max(ansi.StringWidth(labels[i]), matrixMinWidth)
 	}
-	for _, module := range rows {
-		widths[0] = max(widths[0], ansi.StringWidth(matrixProjectLabel(module)))
+	for _, row := range cells {
+		for i, cell := range row {
+			widths[i] = max(widths[i], ansi.StringWidth(cell))
+		}
 	}
 
 	writeBorder(w, boxTopLeft, boxTeeDown, boxTopRight, widths)
@@ -89,26 +134,7 @@ func renderDependencyMatrix(w io.Writer, modules []moduleInfo, refs versionRefs,
 	}
 	writeMatrixRow(w, headers, widths)
 	writeBorder(w, boxTeeRight, boxCross, boxTeeLeft, widths)
-
-	for _, module := range rows {
-		dependencies := make(map[string]struct{}, len(module.Uses))
-		for _, dependency := range module.Uses {
-			dependencies[dependency] = struct{}{}
-		}
-
-		row := make([]string, len(widths))
-		row[0] = matrixProjectLabel(module)
-		for i, candidate := range columns {
-			if _, ok := dependencies[candidate.Name]; ok {
-				color := components.ColorGreen
-				mark := dependencyMark
-				if dependencyOutdated(refs, tags, module.Name, candidate.Name) {
-					color = components.ColorYellow
-					mark += "*"
-				}
-				row[i+1] = color + mark + components.ColorReset
-			}
-		}
+	for _, row := range cells {
 		writeMatrixRow(w, row, widths)
 	}
 	writeBorder(w, boxBottomLeft, boxTeeUp, boxBottomRight, widths)
@@ -168,3 +194,106 @@ func matrixSummary(modules []moduleInfo, refs versionRefs, tags latestTags) (ahe
 	}
 	return ahead, localChanges, outdated
 }
+
+// matrixEntry is one cell of the exported dependency matrix: a module
+// requiring a workspace module, with the version it requires and the latest
+// tag of the dependency.
+type matrixEntry struct {
+	Project    string `json:"project"`
+	Dependency string `json:"dependency"`
+	Version    string `json:"version"`
+	Latest     string `json:"latest"`
+	Outdated   bool   `json:"outdated"`
+}
+
+// matrixEntries lists the filled cells of the dependency matrix, row by row.
+func matrixEntries(modules []moduleInfo, refs versionRefs, tags latestTags) []matrixEntry {
+	rows, columns := matrixLayout(modules)
+	entries := []matrixEntry{}
+	for _, module := range rows {
+		dependencies := make(map[string]struct{}, len(module.Uses))
+		for _, dependency := range module.Uses {
+			dependencies[dependency] = struct{}{}
+		}
+		for _, candidate := range columns {
+			if _, ok := dependencies[candidate.Name]; !ok {
+				continue
+			}
+			entries = append(entries, matrixEntry{
+				Project:    module.Name,
+				Dependency: candidate.Name,
+				Version:    refs[module.Name][candidate.Name],
+				Latest:     tags[candidate.Name],
+				Outdated:   dependencyOutdated(refs, tags, module.Name, candidate.Name),
+			})
+		}
+	}
+	return entries
+}
+
+// matrixCellValue spells out a filled cell of the exported matrix: the
+// version required, and how it compares to the latest tag of the dependency,
+// such as "v1.2.0 (latest v1.3.0, outdated)".
+func matrixCellValue(e matrixEntry) string {
+	switch {
+	case e.Outdated:
+		return fmt.Sprintf("%s (latest %s, outdated)", e.Version, e.Latest)
+	case e.Latest != "" && e.Version == e.Latest:
+		return e.Version + " (latest)"
+	}
+	return e.Version
+}
+
+// matrixTable lays the dependency matrix out for a spreadsheet: a header of
+// the workspace modules required, then a row per project with the cell value
+// of each module it requires, empty where it requires none.
+func matrixTable(modules []moduleInfo, refs versionRefs, tags latestTags) [][]string {
+	rows, columns := matrixLayout(modules)
+	header := []string{"project"}
+	index := make(map[string]int, len(columns))
+	for i, module := range columns {
+		header = append(header, module.Name)
+		index[module.Name] = i + 1
+	}
+	cells := make(map[string][]string, len(rows))
+	for _, module := range rows {
+		cells[module.Name] = make([]string, len(header))
+		cells[module.Name][0] = module.Name
+	}
+	for _, e := range matrixEntries(modules, refs, tags) {
+		cells[e.Project][index[e.Dependency]] = matrixCellValue(e)
+	}
+	table := [][]string{header}
+	for _, module := range rows {
+		table = append(table, cells[module.Name])
+	}
+	return table
+}
+
+// writeMatrix writes the dependency matrix as CSV or TSV, a row per project
+// and a column per workspace module it may require, or as JSON, a record per
+// project and workspace module it requires.
+func writeMatrix(w io.Writer, modules []moduleInfo, refs versionRefs, tags latestTags, format string) error {
+	switch format {
+	case formatJSON:
+		data, err := json.MarshalIndent(matrixEntries(modules, refs, tags), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case formatCSV, formatTSV:
+		var buf bytes.Buffer
+		out := csv.NewWriter(&buf)
+		if format == formatTSV {
+			out.Comma = '\t'
+		}
+		out.WriteAll(matrixTable(modules, refs, tags))
+		if err := out.Error(); err != nil {
+			return err
+		}
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	return fmt.Errorf("-t: --format takes csv, tsv or json, not %q", format)
+}
@@ -5,32 +5,114 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/titpetric/tools/worktree/config"
+)
+
+// The output formats --format selects.
+const (
+	formatCSV  = "csv"
+	formatTSV  = "tsv"
+	formatJSON = "json"
+	formatGFM  = "gfm"
 )
 
 // Options holds command-line options for worktree.
 type Options struct {
-	Update     bool
-	UpdateAll  bool
-	Pull       bool
-	All        bool
-	PUML       bool
-	D2         bool
-	Matrix     bool
-	Verbose    bool
-	Configure  bool
-	GoVersion  string
-	Release    string
-	FilterPath string
-	FilterArg  string
-	Skipped    int
+	Update       bool
+	UpdateAll    bool
+	Pull         bool
+	Push         bool
+	Fetch        bool
+	DryRun       bool
+	All          bool
+	PUML         bool
+	D2           bool
+	Matrix       bool
+	Versions     bool
+	SVG          string
+	Width        int
+	Color        string
+	Verbose      bool
+	Configure    bool
+	ConfigArgs   []string
+	Workspace    bool
+	Branches     bool
+	Deps         bool
+	Align        bool
+	Vuln         bool
+	VulnDB       string
+	Fix          bool
+	Licenses     bool
+	Changelog    bool
+	At           string
+	Snapshot     string
+	SnapshotArgs []string
+	Format       string
+	Prune        bool
+	Strict       bool
+	StaleDays    int
+	Commit       bool
+	Branch       string
+	GoVersion    string
+	Toolchain    string
+	Release      string
+	Retract      string
+	Reason       string
+	FilterPath   string
+	FilterArg    string
+	Group        string
+	Where        string
+	Skipped      int
+
+	// Columns, Sort and UnreleasedAge come from the display settings of the
+	// configuration.
+	Columns       []string
+	Sort          string
+	UnreleasedAge time.Duration
+
+	// set holds the flags given on the command line, which win over the
+	// configuration.
+	set map[string]bool
 }
 
-// commandConfig opens the setup screen instead of scanning the workspace.
+// ApplyDisplay takes the display settings of the configuration. The ones a
+// flag also sets, --all and -v, only apply when the flag was not given.
+func (o *Options) ApplyDisplay(d config.Display) {
+	o.Columns = d.TableColumns()
+	o.Sort = d.Sort
+	o.UnreleasedAge = d.UnreleasedAge()
+	if !o.set["all"] {
+		o.All = d.ShowAll
+	}
+	if !o.set["v"] {
+		o.Verbose = d.Verbose
+	}
+}
+
+// commandConfig opens the setup screen instead of scanning the workspace, or
+// with a further subcommand such as "get" or "set", edits the configuration
+// without it.
 const commandConfig = "config"
 
 // valueFlags lists the flags that take a value as a separate argument.
-var valueFlags = map[string]bool{"-go": true, "--go": true}
+var valueFlags = map[string]bool{
+	"-go": true, "--go": true,
+	"-toolchain": true, "--toolchain": true,
+	"-branch": true, "--branch": true,
+	"-days": true, "--days": true,
+	"-where": true, "--where": true,
+	"-db": true, "--db": true,
+	"-svg": true, "--svg": true,
+	"-width": true, "--width": true,
+	"-color": true, "--color": true,
+	"-format": true, "--format": true,
+	"-reason": true, "--reason": true,
+	"-at": true, "--at": true,
+}
 
 // ParseOptions parses command-line flags and returns Options.
 func ParseOptions() *Options {
@@ -57,19 +139,61 @@ func ParseOptions() *Options {
 	flag.BoolVar(&opts.Update, "u", false, "update the workspace dependencies that are behind their latest tag, and tidy")
 	flag.BoolVar(&opts.UpdateAll, "U", false, "update every dependency with go get -u ./..., including ones outside the workspace")
 	flag.BoolVar(&opts.Pull, "pull", false, "pull new changes for each git repository")
+	flag.BoolVar(&opts.Push, "push", false, "push unpushed commits, new branches and new tags of each git repository")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "with --push, list what would be pushed without pushing")
+	flag.BoolVar(&opts.Fetch, "fetch", false, "fetch every git repository first, so commits behind upstream are current")
 	flag.BoolVar(&opts.All, "all", false, "include all modules (default: skip modules without releases/changes)")
 	flag.BoolVar(&opts.PUML, "puml", false, "output PlantUML dependency diagram to stdout")
 	flag.BoolVar(&opts.D2, "d2", false, "output D2 dependency diagram to stdout")
 	flag.BoolVar(&opts.Matrix, "t", false, "output dependency matrix to stdout")
+	flag.StringVar(&opts.SVG, "svg", "", "render the table, or the -t matrix, to this SVG file instead of stdout")
+	flag.StringVar(&opts.Color, "color", colorAuto, "color the output: auto, always or never")
+	flag.IntVar(&opts.Width, "width", 0, "fit tables to this many columns instead of the terminal width")
+	flag.BoolVar(&opts.Versions, "versions", false, "with -t, show the required versions in the matrix cells")
 	flag.BoolVar(&opts.Verbose, "v", false, "verbose output: show module details and commands run during updates")
 	flag.StringVar(&opts.GoVersion, "go", "", "set the go directive of every go.mod and go.work to this version, then update dependencies")
+	flag.StringVar(&opts.Toolchain, "toolchain", "", "set the toolchain directive of every go.mod and go.work, such as go1.27.2, or none to remove it")
+	flag.BoolVar(&opts.Commit, "commit", false, "commit the go.mod and go.sum changes of an update, one commit per git repository")
+	flag.StringVar(&opts.Branch, "branch", "", "create this branch for the update commits; implies --commit")
+	flag.StringVar(&opts.At, "at", "", "show the workspace as it was at a date, such as 2026-06-01, or a git ref")
+	flag.StringVar(&opts.Where, "where", "", "only show the modules matching this expression, such as 'outdated > 0 && branch != \"main\"'")
+	flag.BoolVar(&opts.Workspace, "workspace", false, "with config, edit the workspace layer at the scan root instead of the user configuration")
+	flag.BoolVar(&opts.Align, "align", false, "with deps, require the highest version of each drifting dependency in every module")
+	flag.StringVar(&opts.VulnDB, "db", "", "with vuln, the OSV database directory; defaults to GOVULNDB=file:///path")
+	flag.BoolVar(&opts.Fix, "fix", false, "with vuln, require the fixed version of each vulnerable dependency")
+	flag.StringVar(&opts.Format, "format", "", "write the table as gfm for a pull request comment; with -t, the matrix as csv, tsv or json; with licenses, the inventory as csv or json")
+	flag.BoolVar(&opts.Strict, "strict", false, "with licenses, exit with status 1 when a denied license is found")
+	flag.StringVar(&opts.Reason, "reason", "", "with retract, the rationale comment of the retract directive")
+	flag.BoolVar(&opts.Prune, "prune", false, "with branches, delete the merged branches after confirmation")
+	flag.IntVar(&opts.StaleDays, "days", defaultStaleDays, "with branches, report branches without a commit for this many days")
 	flag.Parse()
+	opts.set = make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		opts.set[f.Name] = true
+	})
+
+	if !slices.Contains(colorModes, opts.Color) {
+		fmt.Fprintf(os.Stderr, "--color takes auto, always or never, not %q\n", opts.Color)
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	// -U is a wider -u, so it implies it.
 	if opts.UpdateAll {
 		opts.Update = true
 	}
 
+	// A branch is only created for the commits, so asking for one asks for
+	// them.
+	if opts.Branch != "" {
+		opts.Commit = true
+	}
+	if opts.Commit && !opts.Update && opts.GoVersion == "" && opts.Toolchain == "" && !opts.Align && !opts.Fix {
+		fmt.Fprintln(os.Stderr, "--commit requires -u, -U, --go, --toolchain, deps --align or vuln --fix")
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	if opts.GoVersion != "" {
 		goVersion, err := parseGoVersion(opts.GoVersion)
 		if err != nil {
@@ -80,31 +204,93 @@ func ParseOptions() *Options {
 		opts.GoVersion = goVersion
 	}
 
+	if opts.Toolchain != "" {
+		toolchain, err := parseToolchain(opts.Toolchain)
+		if err == nil && opts.GoVersion != "" && toolchainBelowGo(toolchain, opts.GoVersion) {
+			err = fmt.Errorf("--toolchain %s is below --go %s", toolchain, opts.GoVersion)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			flag.Usage()
+			os.Exit(2)
+		}
+		opts.Toolchain = toolchain
+	}
+
 	// Resolve subcommands, which take no path filter
 	if flag.NArg() > 0 {
 		switch flag.Arg(0) {
 		case releasePatch, releaseMinor:
 			opts.Release = flag.Arg(0)
 			return opts
+		case commandRetract:
+			if flag.NArg() < 2 {
+				fmt.Fprintln(os.Stderr, "retract requires a version, such as v1.4.2 or \"[v1.0.0, v1.2.0]\"")
+				flag.Usage()
+				os.Exit(2)
+			}
+			opts.Retract = flag.Arg(1)
+			return opts
 		case commandConfig:
 			opts.Configure = true
+			opts.ConfigArgs = flag.Args()[1:]
+			return opts
+		case commandBranches:
+			opts.Branches = true
+			return opts
+		case commandDeps:
+			opts.Deps = true
+			return opts
+		case commandVuln:
+			opts.Vuln = true
+			return opts
+		case commandLicenses:
+			opts.Licenses = true
+			return opts
+		case commandSnapshot:
+			if flag.NArg() < 2 || (flag.Arg(1) != snapshotSave && flag.Arg(1) != snapshotDiff) {
+				fmt.Fprintln(os.Stderr, "snapshot requires save [name] or diff [a] [b]")
+				flag.Usage()
+				os.Exit(2)
+			}
+			opts.Snapshot = flag.Arg(1)
+			opts.SnapshotArgs = flag.Args()[2:]
+			return opts
+		case commandChangelog:
+			opts.Changelog = true
+			if flag.NArg() > 1 {
+				opts.setFilter(flag.Arg(1))
+			}
 			return opts
 		}
 	}
 
+	// Resolve a group, named as @name, in place of a path filter
+	if flag.NArg() > 0 && strings.HasPrefix(flag.Arg(0), "@") {
+		opts.Group = strings.TrimPrefix(flag.Arg(0), "@")
+		return opts
+	}
+
 	// Resolve optional path filter
 	if flag.NArg() > 0 && flag.Arg(0) != "./..." {
-		opts.FilterArg = flag.Arg(0)
-		abs, err := filepath.Abs(opts.FilterArg)
-		if err == nil {
-			if _, err := os.Stat(abs); err == nil {
-				opts.FilterPath = abs
-			}
-		}
-		if opts.FilterPath == "" {
-			opts.FilterPath = opts.FilterArg
-		}
+		opts.setFilter(flag.Arg(0))
 	}
 
 	return opts
 }
+
+// setFilter selects the modules matching arg: a short name, a module path, or
+// a directory, which is made absolute while the working directory is still
+// the one it was given in.
+func (o *Options) setFilter(arg string) {
+	o.FilterArg = arg
+	abs, err := filepath.Abs(arg)
+	if err == nil {
+		if _, err := os.Stat(abs); err == nil {
+			o.FilterPath = abs
+		}
+	}
+	if o.FilterPath == "" {
+		o.FilterPath = o.FilterArg
+	}
+}
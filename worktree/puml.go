@@ -6,10 +6,12 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/titpetric/tools/worktree/components"
+	"github.com/titpetric/tools/worktree/config"
 )
 
-func renderPUML(w io.Writer, modules []moduleInfo) {
+// renderPUML draws the workspace as PlantUML, each module in the package of
+// its group.
+func renderPUML(w io.Writer, modules []moduleInfo, groups config.Groups) {
 	fmt.Fprintln(w, "@startuml")
 	fmt.Fprintln(w, "top to bottom direction")
 	fmt.Fprintln(w, "set namespaceSeparator none")
@@ -20,17 +22,13 @@ func renderPUML(w io.Writer, modules []moduleInfo) {
 	fmt.Fprintln(w, "}")
 	fmt.Fprintln(w)
 
-	// Group by directory path (all segments except last) as flat packages
-	groups := make(map[string][]pumlComponent)
+	// Group by configured group, or else by directory path (all segments
+	// except last), as flat packages
+	packages := make(map[string][]pumlComponent)
 	var groupOrder []string
 	for _, m := range modules {
-		short := components.ShortPath(m.Name)
-		idx := strings.LastIndex(short, "/")
-		pkg, label := "local", short
-		if idx != -1 {
-			pkg, label = short[:idx], short[idx+1:]
-		}
-		if _, seen := groups[pkg]; !seen {
+		pkg, label := diagramGroup(m.Name, groups)
+		if _, seen := packages[pkg]; !seen {
 			groupOrder = append(groupOrder, pkg)
 		}
 		comp := pumlComponent{
@@ -45,17 +43,17 @@ func renderPUML(w io.Writer, modules []moduleInfo) {
 				comp.label = m.Description
 			}
 		}
-		groups[pkg] = append(groups[pkg], comp)
+		packages[pkg] = append(packages[pkg], comp)
 	}
 
 	// Sort groups by module count descending
 	sort.Slice(groupOrder, func(i, j int) bool {
-		return len(groups[groupOrder[i]]) > len(groups[groupOrder[j]])
+		return len(packages[groupOrder[i]]) > len(packages[groupOrder[j]])
 	})
 
 	for _, pkg := range groupOrder {
 		fmt.Fprintf(w, "package \"%s\" {\n", pkg)
-		comps := groups[pkg]
+		comps := packages[pkg]
 		for _, c := range comps {
 			fmt.Fprintf(w, "  component \"%s\" as %s [[%s]]\n", c.label, c.alias, c.link)
 		}
@@ -72,12 +70,7 @@ func renderPUML(w io.Writer, modules []moduleInfo) {
 	modPkg := make(map[string]string)
 	modName := make(map[string]string)
 	for _, m := range modules {
-		short := components.ShortPath(m.Name)
-		idx := strings.LastIndex(short, "/")
-		pkg, name := "local", short
-		if idx != -1 {
-			pkg, name = short[:idx], short[idx+1:]
-		}
+		pkg, name := diagramGroup(m.Name, groups)
 		modPkg[m.Name] = pkg
 		modName[m.Name] = name
 	}
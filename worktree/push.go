@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// pushPlan is what a push of one repository would send.
+type pushPlan struct {
+	remote string
+	branch string
+
+	// upstream is the branch on remote the current branch tracks, empty
+	// for a branch never pushed to a remote.
+	upstream string
+
+	// commits counts the commits the remote does not have yet.
+	commits int
+
+	// tags lists the release tags of the repository's modules the remote
+	// does not have yet.
+	tags []string
+}
+
+// newBranch reports whether the branch has no upstream yet, so pushing it
+// sets one.
+func (p pushPlan) newBranch() bool {
+	return p.upstream == "" && p.branch != "" && p.branch != "HEAD"
+}
+
+// empty reports whether there is nothing to push.
+func (p pushPlan) empty() bool {
+	return p.commits == 0 && len(p.tags) == 0 && !p.newBranch()
+}
+
+// planPush works out what pushing the repository at path, holding the
+// modules in dirs, would send. Commits are counted against the upstream of
+// the current branch, read from its branch configuration; a branch without
+// one on a remote counts the commits no remote branch holds, and is pushed
+// to the remote its upstream would live on. Only the release tags of the
+// modules are pushed, never other local tags.
+func planPush(path string, dirs []string) pushPlan {
+	p := pushPlan{branch: getGitBranch(path)}
+
+	// A branch tracking a local branch has the remote "." and no upstream
+	// on a remote.
+	remote := firstCommandLine(path, "git", "config", "branch."+p.branch+".remote")
+	merge := firstCommandLine(path, "git", "config", "branch."+p.branch+".merge")
+	if remote != "" && remote != "." && merge != "" {
+		p.remote, p.upstream = remote, strings.TrimPrefix(merge, "refs/heads/")
+		if st := getGitStatus(path); st != nil {
+			p.commits = st.Unpushed
+		}
+	} else {
+		p.remote = defaultRemote(path)
+		if n, err := strconv.Atoi(firstCommandLine(path, "git", "rev-list", "--count", "HEAD", "--not", "--remotes")); err == nil {
+			p.commits = n
+		}
+	}
+	if p.remote == "" {
+		return p
+	}
+
+	remoteTags := make(map[string]bool)
+	cmd := exec.Command("git", "ls-remote", "--tags", p.remote)
+	cmd.Dir = path
+	out, err := cmd.Output()
+	if err != nil {
+		// Without the list of remote tags nothing can be said to be new.
+		return p
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		_, ref, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		ref = strings.TrimSuffix(strings.TrimPrefix(ref, "refs/tags/"), "^{}")
+		remoteTags[ref] = true
+	}
+	tags, _ := gitTags(path)
+	for _, dir := range dirs {
+		if _, err := readModulePath(dir); err != nil {
+			continue
+		}
+		prefix, versions := moduleTags(dir, tags)
+		for _, version := range versions {
+			if tag := prefix + version; !remoteTags[tag] && !slices.Contains(p.tags, tag) {
+				p.tags = append(p.tags, tag)
+			}
+		}
+	}
+	sort.Strings(p.tags)
+	return p
+}
+
+// defaultRemote returns the remote a new branch is pushed to: origin when the
+// repository has one, otherwise the first remote listed.
+func defaultRemote(path string) string {
+	cmd := exec.Command("git", "remote")
+	cmd.Dir = path
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	remotes := strings.Fields(string(out))
+	for _, remote := range remotes {
+		if remote == "origin" {
+			return remote
+		}
+	}
+	if len(remotes) > 0 {
+		return remotes[0]
+	}
+	return ""
+}
+
+// describe renders the plan as status lines, in the past tense once pushed
+// or in the conditional for a dry run.
+func (p pushPlan) describe(dryRun bool) []string {
+	verb := "Pushed"
+	if dryRun {
+		verb = "Would push"
+	}
+
+	var lines []string
+	if p.newBranch() {
+		if dryRun {
+			lines = append(lines, fmt.Sprintf("Would set upstream %s/%s.", p.remote, p.branch))
+		} else {
+			lines = append(lines, fmt.Sprintf("Set upstream %s/%s.", p.remote, p.branch))
+		}
+	}
+	switch {
+	case p.commits == 1:
+		lines = append(lines, verb+" 1 commit.")
+	case p.commits > 1:
+		lines = append(lines, fmt.Sprintf("%s %d commits.", verb, p.commits))
+	}
+	switch {
+	case len(p.tags) == 1:
+		lines = append(lines, verb+" tag "+p.tags[0]+".")
+	case len(p.tags) > 1:
+		lines = append(lines, verb+" tags "+strings.Join(p.tags, ", ")+".")
+	}
+	return lines
+}
+
+// pushRepos pushes every git repository holding one of dirs that has
+// something to push: commits its upstream does not have, a branch that has
+// no upstream yet, which is pushed with one set, or release tags of the
+// modules in dirs the remote does not have. The outcome is reported in the table layout of pullRepos. With
+// dryRun nothing is pushed, and the table lists what would be.
+func pushRepos(w io.Writer, dirs []string, dryRun, styled bool) {
+	modules := make(map[string][]string)
+	for _, dir := range dirs {
+		if root, err := gitTopLevel(dir); err == nil {
+			modules[root] = append(modules[root], dir)
+		}
+	}
+
+	var rows [][]string
+	for _, path := range gitRepos(dirs) {
+		remote := firstCommandLine(path, "git", "remote", "-v")
+		p := planPush(path, modules[path])
+
+		s := &status{styled: styled}
+		switch {
+		case p.remote == "":
+			s.add(components.ColorSeparator, "No remote to push to.")
+		case p.empty():
+			s.add(components.ColorGreen, "Nothing to push.")
+		case dryRun:
+			for _, line := range p.describe(true) {
+				s.add(components.ColorAmber, "%s", line)
+			}
+		default:
+			pushed := true
+			if p.newBranch() {
+				pushed = s.run(path, false, "git", "push", "--quiet", "--set-upstream", p.remote, p.branch) == nil
+			} else if p.commits > 0 {
+				pushed = s.run(path, false, "git", "push", "--quiet", p.remote, "HEAD:refs/heads/"+p.upstream) == nil
+			}
+			if pushed && len(p.tags) > 0 {
+				args := []string{"git", "push", "--quiet", p.remote}
+				for _, tag := range p.tags {
+					args = append(args, "refs/tags/"+tag)
+				}
+				pushed = s.run(path, false, args...) == nil
+			}
+			if pushed {
+				for _, line := range p.describe(false) {
+					s.add(components.ColorGreen, "%s", line)
+				}
+			}
+		}
+		rows = append(rows, []string{relPath(path), remote, p.branch, s.String()})
+	}
+	writeSimpleTable(w, []string{"Path", "Remote", "Branch", "Push status"}, rows, styled)
+}
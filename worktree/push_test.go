@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPushReposPushesCommitsAndTags(t *testing.T) {
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	repo := filepath.Join(root, "service")
+	runGit(t, root, "init", "--bare", remote)
+	runGit(t, root, "clone", remote, repo)
+	runGit(t, repo, "config", "user.name", "Test User")
+	runGit(t, repo, "config", "user.email", "test@example.com")
+	writeTestFile(t, filepath.Join(repo, "go.mod"), "module example.com/service\n")
+	runGit(t, repo, "add", "go.mod")
+	runGit(t, repo, "commit", "-m", "initial")
+	runGit(t, repo, "push", "-u", "origin", "HEAD")
+
+	var output bytes.Buffer
+	pushRepos(&output, []string{repo}, false, false)
+	if got := output.String(); !strings.Contains(got, "| Path | Remote | Branch | Push status |") || !strings.Contains(got, "Nothing to push.") {
+		t.Fatalf("pushRepos() on a pushed repository:\n%s", got)
+	}
+
+	writeTestFile(t, filepath.Join(repo, "CHANGELOG.md"), "update\n")
+	runGit(t, repo, "add", "CHANGELOG.md")
+	runGit(t, repo, "commit", "-m", "update")
+	runGit(t, repo, "tag", "v0.1.0")
+	runGit(t, repo, "tag", "backup")
+
+	output.Reset()
+	pushRepos(&output, []string{repo}, true, false)
+	if got := output.String(); !strings.Contains(got, "Would push 1 commit.<br>Would push tag v0.1.0.") {
+		t.Fatalf("pushRepos() dry run did not list the commit and tag:\n%s", got)
+	}
+	if tags := firstCommandLine(remote, "git", "tag", "--list"); tags != "" {
+		t.Fatalf("pushRepos() dry run pushed tags %q", tags)
+	}
+
+	output.Reset()
+	pushRepos(&output, []string{repo}, false, false)
+	if got := output.String(); !strings.Contains(got, "Pushed 1 commit.<br>Pushed tag v0.1.0.") {
+		t.Fatalf("pushRepos() did not report the push:\n%s", got)
+	}
+	if tags := firstCommandLine(remote, "git", "tag", "--list"); tags != "v0.1.0" {
+		t.Fatalf("remote tags = %q, want v0.1.0", tags)
+	}
+	if local, pushed := firstCommandLine(repo, "git", "rev-parse", "HEAD"), firstCommandLine(remote, "git", "rev-parse", "HEAD"); local != pushed {
+		t.Fatalf("remote HEAD = %s, want %s", pushed, local)
+	}
+}
+
+// TestPushReposSetsUpstream checks a branch that was never pushed is pushed
+// with an upstream set.
+func TestPushReposSetsUpstream(t *testing.T) {
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	repo := filepath.Join(root, "service")
+	runGit(t, root, "init", "--bare", remote)
+	runGit(t, root, "clone", remote, repo)
+	runGit(t, repo, "config", "user.name", "Test User")
+	runGit(t, repo, "config", "user.email", "test@example.com")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, repo, "push", "-u", "origin", "HEAD")
+	runGit(t, repo, "checkout", "-b", "feature")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "feature")
+
+	var output bytes.Buffer
+	pushRepos(&output, []string{repo}, false, false)
+	if got := output.String(); !strings.Contains(got, "Set upstream origin/feature.<br>Pushed 1 commit.") {
+		t.Fatalf("pushRepos() did not set the upstream:\n%s", got)
+	}
+	if upstream := firstCommandLine(repo, "git", "rev-parse", "--abbrev-ref", "@{u}"); upstream != "origin/feature" {
+		t.Fatalf("upstream = %q, want origin/feature", upstream)
+	}
+}
+
+// TestPushReposLocalUpstream checks a branch tracking a local branch is
+// pushed to a remote, not to one named after the local branch.
+func TestPushReposLocalUpstream(t *testing.T) {
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	repo := filepath.Join(root, "service")
+	runGit(t, root, "init", "--bare", remote)
+	runGit(t, root, "clone", remote, repo)
+	runGit(t, repo, "config", "user.name", "Test User")
+	runGit(t, repo, "config", "user.email", "test@example.com")
+	runGit(t, repo, "checkout", "-b", "main")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, repo, "push", "-u", "origin", "main")
+	runGit(t, repo, "checkout", "-b", "feature")
+	runGit(t, repo, "branch", "--set-upstream-to=main")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "feature")
+
+	if p := planPush(repo, nil); p.remote != "origin" || p.upstream != "" || p.commits != 1 {
+		t.Fatalf("planPush() = %+v, want a new branch on origin with 1 commit", p)
+	}
+}
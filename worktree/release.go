@@ -30,12 +30,26 @@ func gitTags(dir string) ([]string, error) {
 	return tags, nil
 }
 
+// moduleTags returns the tag prefix of the module in dir and the versions of
+// the tags it is released with, as the go command reads them; see
+// gitTagPrefix. When dir holds no go.mod, the git repository is tagged as a
+// whole and every tag is returned.
+func moduleTags(dir string, tags []string) (string, []string) {
+	modPath, err := readModulePath(dir)
+	if err != nil {
+		return "", tags
+	}
+	prefix := gitTagPrefix(dir, modPath)
+	return prefix, moduleVersions(tags, prefix, pathMajor(modPath))
+}
+
 // releaseCommands returns the git commands that tag and push the next patch
-// or minor release for the given tags. The output is meant to be piped into
+// or minor release for the given versions, as the tag prefix of a nested
+// module followed by the version. The output is meant to be piped into
 // "sh -x". When no release tag exists yet, the version starts at v0.0.0 and a
 // shell comment records that.
-func releaseCommands(tags []string, kind string) ([]string, error) {
-	latest, found := LatestRelease(tags)
+func releaseCommands(versions []string, prefix, kind string) ([]string, error) {
+	latest, found := LatestRelease(versions)
 	if !found {
 		latest = Version{Prefix: "v"}
 	}
@@ -55,7 +69,7 @@ func releaseCommands(tags []string, kind string) ([]string, error) {
 		lines = append(lines, fmt.Sprintf("# no release tags found, starting from %s", latest))
 	}
 	return append(lines,
-		fmt.Sprintf("git tag %s", next),
+		fmt.Sprintf("git tag %s%s", prefix, next),
 		"git push --tags",
 	), nil
 }
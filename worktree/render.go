@@ -3,10 +3,13 @@ package main
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/x/ansi"
 	"github.com/titpetric/tools/worktree/components"
+	"github.com/titpetric/tools/worktree/config"
 )
 
 // Light rounded box-drawing characters
@@ -24,16 +27,123 @@ const (
 	boxCross       = "┼"
 )
 
-func renderTables(w io.Writer, modules []moduleInfo, opts *Options, styled bool) {
-	headers := []string{"Module", "Latest", "Go", "Git Branch", "Git State", "Usage"}
-	numCols := len(headers)
+// tableColumn is one column the workspace table can show, named as in
+// config.Columns.
+type tableColumn struct {
+	header string
+	cell   func(m moduleInfo, c cellContext) components.Cell
+}
+
+// cellContext holds what a cell needs beyond its own module.
+type cellContext struct {
+	verbose  bool
+	latestGo Version
+	haveGo   bool
+
+	// now is when the table is rendered, and unreleasedAge the age at
+	// which commits waiting for a release are flagged.
+	now           time.Time
+	unreleasedAge time.Duration
+}
+
+// tableColumns holds the columns of the workspace table by name.
+var tableColumns = map[string]tableColumn{
+	"module": {"Module", func(m moduleInfo, c cellContext) components.Cell {
+		if c.verbose {
+			cell := components.ModuleVerbose(m.Description, m.Path, m.Name)
+			return append(cell, components.Retracted(retractionNames(m.Retractions))...)
+		}
+		return components.Module(m.Path)
+	}},
+	"latest": {"Latest", func(m moduleInfo, c cellContext) components.Cell {
+		return components.Latest(m.Latest, unreleasedStale(m.Activity, c.unreleasedAge, c.now))
+	}},
+	"released": {"Released", func(m moduleInfo, c cellContext) components.Cell {
+		return components.Date(m.Activity.Released)
+	}},
+	"age": {"Age", func(m moduleInfo, c cellContext) components.Cell {
+		released := m.Activity.Released
+		return components.Age(daysSince(released, c.now), !released.IsZero(), unreleasedStale(m.Activity, c.unreleasedAge, c.now))
+	}},
+	"commit": {"Last Commit", func(m moduleInfo, c cellContext) components.Cell {
+		return components.Date(m.Activity.LastCommit)
+	}},
+	"contributors": {"Contributors", func(m moduleInfo, c cellContext) components.Cell {
+		return components.Contributors(m.Activity.Contributors)
+	}},
+	"go": {"Go", func(m moduleInfo, c cellContext) components.Cell {
+		return components.GoVersion(m.GoVersion, c.haveGo && goVersionOutdated(m.GoVersion, c.latestGo))
+	}},
+	"toolchain": {"Toolchain", func(m moduleInfo, c cellContext) components.Cell {
+		return components.Toolchain(m.Toolchain, toolchainBelowGo(m.Toolchain, m.GoVersion))
+	}},
+	"branch": {"Git Branch", func(m moduleInfo, c cellContext) components.Cell {
+		return m.GitState.Branch()
+	}},
+	"state": {"Git State", func(m moduleInfo, c cellContext) components.Cell {
+		return gitStateCell(m.GitState, c.verbose)
+	}},
+	"usage": {"Usage", func(m moduleInfo, c cellContext) components.Cell {
+		if c.verbose {
+			return m.Usage.Verbose()
+		}
+		return m.Usage.Compact()
+	}},
+}
+
+// gitStateCell renders the git state of a module, in detail when verbose.
+func gitStateCell(g *components.Git, verbose bool) components.Cell {
+	if verbose {
+		return g.StateVerbose()
+	}
+	return g.State()
+}
+
+// skipModule reports whether a module has nothing to report: no git state,
+// no commits behind its upstream and no outdated dependents. The rule holds
+// whichever columns are shown.
+func skipModule(m moduleInfo, verbose bool) bool {
+	return gitStateCell(m.GitState, verbose).Empty() && m.GitState.Behind == 0 && m.Outdated == 0 && !requiresRetracted(m)
+}
 
+// requiresRetracted reports whether a workspace module requires a version of
+// m that m retracts.
+func requiresRetracted(m moduleInfo) bool {
+	for _, d := range m.Usage.UsedBy {
+		if d.Retracted != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// selectColumns returns the known table columns of names, in order, with
+// their names and headers. No names selects config.DefaultColumns.
+func selectColumns(names []string) ([]string, []tableColumn, []string) {
+	if len(names) == 0 {
+		names = config.DefaultColumns
+	}
+	var selected []string
+	var columns []tableColumn
+	var headers []string
+	for _, name := range names {
+		if column, ok := tableColumns[name]; ok {
+			selected = append(selected, name)
+			columns = append(columns, column)
+			headers = append(headers, column.header)
+		}
+	}
+	return selected, columns, headers
+}
+
+// visibleModules returns the modules the table shows, counting the skipped
+// ones in opts.Skipped.
+func visibleModules(modules []moduleInfo, opts *Options) []moduleInfo {
 	// Check if all modules would be skipped; if so, show them all (only when not verbose)
 	if !opts.All && !opts.Verbose {
 		allSkipped := true
 		for _, m := range modules {
-			g := m.GitState
-			if !g.State().Empty() || m.Outdated > 0 {
+			if !skipModule(m, false) {
 				allSkipped = false
 				break
 			}
@@ -43,53 +153,44 @@ func renderTables(w io.Writer, modules []moduleInfo, opts *Options, styled bool)
 		}
 	}
 
-	latestGo, haveGo := latestGoVersion(modules)
-
-	var rows []components.Rows
+	var visible []moduleInfo
 	for _, m := range modules {
-		cells := make(components.Rows, numCols)
-
-		g := m.GitState
-		goOutdated := haveGo && goVersionOutdated(m.GoVersion, latestGo)
-
-		if opts.Verbose {
-			cells[0] = components.ModuleVerbose(m.Description, m.Path, m.Name)
-			cells[1] = components.Latest(m.Latest)
-			cells[2] = components.GoVersion(m.GoVersion, goOutdated)
-			cells[3] = g.Branch()
-			cells[4] = g.StateVerbose()
-			cells[5] = m.Usage.Verbose()
-		} else {
-			cells[0] = components.Module(m.Path)
-			cells[1] = components.Latest(m.Latest)
-			cells[2] = components.GoVersion(m.GoVersion, goOutdated)
-			cells[3] = g.Branch()
-			cells[4] = g.State()
-			cells[5] = m.Usage.Compact()
-		}
-
-		// Skip modules where git state and usage cells are both empty
-		if !opts.All && cells[4].Empty() && m.Outdated == 0 {
+		// Skip modules with nothing to report, unless the checkout is behind
+		// its upstream
+		if !opts.All && skipModule(m, opts.Verbose) {
 			opts.Skipped++
 			continue
 		}
-
-		rows = append(rows, cells)
+		visible = append(visible, m)
 	}
+	return visible
+}
 
-	// Compute column widths
-	widths := make([]int, numCols)
-	for i, h := range headers {
-		widths[i] = ansi.StringWidth(h)
-	}
-	for _, row := range rows {
-		for colIdx, cell := range row {
-			if w := cell.Width(); w > widths[colIdx] {
-				widths[colIdx] = w
-			}
+// newCellContext returns what the cells of modules are rendered with.
+func newCellContext(modules []moduleInfo, opts *Options, verbose bool) cellContext {
+	latestGo, haveGo := latestGoVersion(modules)
+	return cellContext{verbose: verbose, latestGo: latestGo, haveGo: haveGo, now: time.Now(), unreleasedAge: opts.UnreleasedAge}
+}
+
+func renderTables(w io.Writer, modules []moduleInfo, opts *Options, styled bool) {
+	names, columns, headers := selectColumns(opts.Columns)
+	numCols := len(headers)
+	ctx := newCellContext(modules, opts, opts.Verbose)
+
+	var rows []components.Rows
+	for _, m := range visibleModules(modules, opts) {
+		cells := make(components.Rows, numCols)
+		for i, column := range columns {
+			cells[i] = column.cell(m, ctx)
 		}
+		rows = append(rows, cells)
 	}
+
 	if styled {
+		// The columns are fitted to the terminal, dropping and narrowing
+		// them when the content is wider.
+		var widths []int
+		headers, rows, widths = fitTable(names, headers, rows, terminalWidth(w, opts))
 		writeBorder(w, boxTopLeft, boxTeeDown, boxTopRight, widths)
 		writeHeaderRow(w, headers, widths)
 		writeBorder(w, boxTeeRight, boxCross, boxTeeLeft, widths)
@@ -104,6 +205,13 @@ func renderTables(w io.Writer, modules []moduleInfo, opts *Options, styled bool)
 		writeMarkdownTable(w, headers, rows)
 	}
 
+	renderTableFooter(w, modules, opts, styled)
+}
+
+// renderTableFooter writes the notes below the workspace table: the
+// outdated dependencies to update, the toolchain and retraction warnings,
+// and the modules skipped.
+func renderTableFooter(w io.Writer, modules []moduleInfo, opts *Options, styled bool) {
 	headerColor, borderColor, yellow, reset := "", "", "", ""
 	if styled {
 		headerColor, borderColor = components.ColorHeader, components.ColorBorder
@@ -120,6 +228,9 @@ func renderTables(w io.Writer, modules []moduleInfo, opts *Options, styled bool)
 			borderColor, yellow, reset, borderColor, outdated, reset)
 	}
 
+	renderToolchainWarnings(w, modules, styled)
+	renderRetractionWarnings(w, modules, styled)
+
 	// Print skipped summary
 	if opts.Skipped > 0 {
 		fmt.Fprintf(w, "%sSkipped %d modules, use --all to show%s\n",
@@ -127,6 +238,46 @@ func renderTables(w io.Writer, modules []moduleInfo, opts *Options, styled bool)
 	}
 }
 
+// sortModules orders the modules by one of config.SortKeys. The default,
+// usage, puts the most used modules first, then the ones with the fewest
+// dependencies of their own. Every order falls back to the module name.
+func sortModules(modules []moduleInfo, key string) {
+	sort.SliceStable(modules, func(i, j int) bool {
+		a, b := modules[i], modules[j]
+		switch key {
+		case "name":
+		case "path":
+			if a.Path != b.Path {
+				return a.Path < b.Path
+			}
+		case "ahead":
+			if ahead, other := gitAhead(a), gitAhead(b); ahead != other {
+				return ahead > other
+			}
+		case "outdated":
+			if a.Outdated != b.Outdated {
+				return a.Outdated > b.Outdated
+			}
+		default:
+			if len(a.UsedBy) != len(b.UsedBy) {
+				return len(a.UsedBy) > len(b.UsedBy)
+			}
+			if len(a.Uses) != len(b.Uses) {
+				return len(a.Uses) < len(b.Uses)
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// gitAhead returns the commits a module has since its latest tag.
+func gitAhead(m moduleInfo) int {
+	if m.GitState == nil {
+		return 0
+	}
+	return m.GitState.Ahead
+}
+
 // latestGoVersion returns the highest go directive the workspace declares,
 // the version every module's go column is compared against. It reports false
 // when no module declares a version this can parse.
@@ -161,6 +312,9 @@ func buildUsage(refs versionRefs, tags latestTags, m moduleInfo) (components.Usa
 			d.Outdated = true
 			outdated++
 		}
+		if version := refs[dep][m.Name]; retracted(version, m.Retractions) {
+			d.Retracted = version
+		}
 		u.UsedBy = append(u.UsedBy, d)
 	}
 	for _, dep := range m.Uses {
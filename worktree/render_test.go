@@ -1,6 +1,7 @@
 package main
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -107,3 +108,55 @@ func TestRenderTablesStripsModuleHost(t *testing.T) {
 		t.Errorf("renderTables() output missing the module path:\n%s", out.String())
 	}
 }
+
+// TestRenderTablesColumns checks the table shows the configured columns in
+// their configured order, and skips a module by its git state even when the
+// state column is hidden.
+func TestRenderTablesColumns(t *testing.T) {
+	modules := []moduleInfo{
+		{Name: "example.com/dirty", Path: "./dirty", Latest: "v1.0.0", GitState: &components.Git{BranchName: "main", Unpushed: 1}},
+		{Name: "example.com/clean", Path: "./clean", Latest: "v1.0.0", GitState: &components.Git{BranchName: "main"}},
+	}
+
+	var out strings.Builder
+	renderTables(&out, modules, &Options{Columns: []string{"usage", "module"}}, false)
+
+	if got, want := strings.SplitN(out.String(), "\n", 2)[0], "| Usage | Module |"; got != want {
+		t.Fatalf("renderTables() header = %q, want %q", got, want)
+	}
+	if !strings.Contains(out.String(), "dirty") || strings.Contains(out.String(), "clean |") {
+		t.Fatalf("renderTables() did not skip the clean module:\n%s", out.String())
+	}
+}
+
+func TestSortModules(t *testing.T) {
+	modules := func() []moduleInfo {
+		return []moduleInfo{
+			{Name: "example.com/b", Path: "./z", Outdated: 1, GitState: &components.Git{Ahead: 5}},
+			{Name: "example.com/a", Path: "./y", UsedBy: []string{"example.com/b"}},
+			{Name: "example.com/c", Path: "./x", Outdated: 3, GitState: &components.Git{Ahead: 1}},
+		}
+	}
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"", []string{"example.com/a", "example.com/b", "example.com/c"}},
+		{"usage", []string{"example.com/a", "example.com/b", "example.com/c"}},
+		{"name", []string{"example.com/a", "example.com/b", "example.com/c"}},
+		{"path", []string{"example.com/c", "example.com/a", "example.com/b"}},
+		{"ahead", []string{"example.com/b", "example.com/c", "example.com/a"}},
+		{"outdated", []string{"example.com/c", "example.com/b", "example.com/a"}},
+	}
+	for _, test := range tests {
+		got := modules()
+		sortModules(got, test.key)
+		var names []string
+		for _, m := range got {
+			names = append(names, m.Name)
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("sortModules(%q) = %v, want %v", test.key, names, test.want)
+		}
+	}
+}
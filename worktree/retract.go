@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// commandRetract retracts published versions of the module in the current
+// directory.
+const commandRetract = "retract"
+
+// parseRetraction reads the versions a retract argument names: a single
+// version, such as v1.4.2, or a closed range written as in go.mod, such as
+// "[v1.0.0, v1.2.0]".
+func parseRetraction(arg string) (modfile.VersionInterval, error) {
+	arg = strings.TrimSpace(arg)
+	low, high := arg, arg
+	if strings.HasPrefix(arg, "[") && strings.HasSuffix(arg, "]") {
+		var ok bool
+		low, high, ok = strings.Cut(strings.TrimSuffix(strings.TrimPrefix(arg, "["), "]"), ",")
+		if !ok {
+			return modfile.VersionInterval{}, fmt.Errorf("invalid version range %q, want [low, high]", arg)
+		}
+		low, high = strings.TrimSpace(low), strings.TrimSpace(high)
+	}
+	for _, v := range []string{low, high} {
+		if !semver.IsValid(v) || semver.Canonical(v) != v {
+			return modfile.VersionInterval{}, fmt.Errorf("invalid version %q, want a full semantic version such as v1.4.2", v)
+		}
+	}
+	if semver.Compare(low, high) > 0 {
+		return modfile.VersionInterval{}, fmt.Errorf("invalid version range %q, %s is above %s", arg, low, high)
+	}
+	return modfile.VersionInterval{Low: low, High: high}, nil
+}
+
+// formatRetraction writes versions the way go.mod does: a single version
+// bare, a range in brackets.
+func formatRetraction(vi modfile.VersionInterval) string {
+	if vi.Low == vi.High {
+		return vi.Low
+	}
+	return "[" + vi.Low + ", " + vi.High + "]"
+}
+
+// retracted reports whether version falls in one of the retractions.
+func retracted(version string, retractions []modfile.VersionInterval) bool {
+	for _, vi := range retractions {
+		if semver.Compare(vi.Low, version) <= 0 && semver.Compare(version, vi.High) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// retractionNames formats retractions as go.mod writes them.
+func retractionNames(retractions []modfile.VersionInterval) []string {
+	var names []string
+	for _, vi := range retractions {
+		names = append(names, formatRetraction(vi))
+	}
+	return names
+}
+
+// readRetractions returns the retract directives of the go.mod in dir.
+func readRetractions(dir string) []modfile.VersionInterval {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil
+	}
+	mod, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return nil
+	}
+	var retractions []modfile.VersionInterval
+	for _, r := range mod.Retract {
+		retractions = append(retractions, r.VersionInterval)
+	}
+	return retractions
+}
+
+// addRetraction adds a retract directive to the go.mod in dir, with reason
+// as its rationale comment. It reports false, leaving the file alone, when
+// go.mod already retracts exactly those versions.
+func addRetraction(dir string, vi modfile.VersionInterval, reason string) (bool, error) {
+	path := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	mod, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range mod.Retract {
+		if r.VersionInterval == vi {
+			return false, nil
+		}
+	}
+	if err := mod.AddRetract(vi, reason); err != nil {
+		return false, err
+	}
+	mod.Cleanup()
+	return true, writeModFile(path, mod.Syntax)
+}
+
+// retractCommands returns the git commands that publish a retraction: the
+// go.mod holding it is committed and released as the next patch version,
+// since the go command only learns of a retraction from the latest release.
+// The versions and tag prefix are the module's, as releaseCommands takes them.
+// The output is meant to be piped into "sh -x".
+// A version that is not tagged is still retracted, since a retraction may
+// be published ahead of a version, with a shell comment noting it.
+func retractCommands(versions []string, prefix string, vi modfile.VersionInterval) ([]string, error) {
+	if _, found := LatestRelease(versions); !found {
+		return nil, fmt.Errorf("nothing to retract: no release tags found")
+	}
+	release, err := releaseCommands(versions, prefix, releasePatch)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	if vi.Low == vi.High && !slices.Contains(versions, vi.Low) {
+		lines = append(lines, fmt.Sprintf("# %s is not tagged in this repository", vi.Low))
+	}
+	lines = append(lines, fmt.Sprintf("git commit -m %s go.mod", shellQuote("go.mod: retract "+formatRetraction(vi))))
+	return append(lines, release...), nil
+}
+
+// renderRetractionWarnings lists the workspace modules that still require a
+// version a dependency retracts, whichever columns the table shows.
+func renderRetractionWarnings(w io.Writer, modules []moduleInfo, styled bool) {
+	for _, m := range modules {
+		for _, d := range m.Usage.UsedBy {
+			if d.Retracted == "" {
+				continue
+			}
+			line := fmt.Sprintf("%s requires %s %s, which is retracted", d.Name, components.ShortPath(m.Name), d.Retracted)
+			fmt.Fprintln(w, colorLines(line, components.ColorRed, styled))
+		}
+	}
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
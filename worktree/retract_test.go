@@ -0,0 +1,149 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+func TestParseRetraction(t *testing.T) {
+	valid := map[string]modfile.VersionInterval{
+		"v1.4.2":             {Low: "v1.4.2", High: "v1.4.2"},
+		" v1.4.2 ":           {Low: "v1.4.2", High: "v1.4.2"},
+		"[v1.0.0, v1.2.0]":   {Low: "v1.0.0", High: "v1.2.0"},
+		"[v1.0.0,v1.0.0]":    {Low: "v1.0.0", High: "v1.0.0"},
+		"v2.0.0-rc.1":        {Low: "v2.0.0-rc.1", High: "v2.0.0-rc.1"},
+		"[v0.1.0, v0.9.0-1]": {Low: "v0.1.0", High: "v0.9.0-1"},
+	}
+	for input, want := range valid {
+		got, err := parseRetraction(input)
+		if err != nil || got != want {
+			t.Fatalf("parseRetraction(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "1.4.2", "v1.4", "[v1.0.0]", "[v1.2.0, v1.0.0]", "[v1.0.0, latest]"} {
+		if got, err := parseRetraction(input); err == nil {
+			t.Fatalf("parseRetraction(%q) = %v, want an error", input, got)
+		}
+	}
+}
+
+func TestRetracted(t *testing.T) {
+	retractions := []modfile.VersionInterval{
+		{Low: "v1.4.2", High: "v1.4.2"},
+		{Low: "v1.0.0", High: "v1.2.0"},
+	}
+	for version, want := range map[string]bool{
+		"v1.4.2": true,
+		"v1.0.0": true,
+		"v1.1.5": true,
+		"v1.2.0": true,
+		"v1.2.1": false,
+		"v1.4.3": false,
+		"":       false,
+	} {
+		if got := retracted(version, retractions); got != want {
+			t.Errorf("retracted(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestAddRetraction(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "go.mod")
+	writeTestFile(t, path, "module example.com/lib\n\ngo 1.26\n\nrequire example.com/dep v1.0.0\n")
+
+	added, err := addRetraction(root, modfile.VersionInterval{Low: "v1.4.2", High: "v1.4.2"}, "breaks the config loader")
+	if err != nil || !added {
+		t.Fatalf("addRetraction() = %v, %v, want it added", added, err)
+	}
+	added, err = addRetraction(root, modfile.VersionInterval{Low: "v1.0.0", High: "v1.2.0"}, "")
+	if err != nil || !added {
+		t.Fatalf("addRetraction(range) = %v, %v, want it added", added, err)
+	}
+	got := readTestFile(t, path)
+	for _, want := range []string{
+		"// breaks the config loader\n",
+		"v1.4.2",
+		"[v1.0.0, v1.2.0]",
+		"require example.com/dep v1.0.0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("go.mod missing %q:\n%s", want, got)
+		}
+	}
+
+	// The same retraction again leaves the file alone.
+	added, err = addRetraction(root, modfile.VersionInterval{Low: "v1.4.2", High: "v1.4.2"}, "again")
+	if err != nil || added {
+		t.Fatalf("addRetraction() again = %v, %v, want it skipped", added, err)
+	}
+	if again := readTestFile(t, path); again != got {
+		t.Fatalf("a repeated retraction rewrote go.mod:\n%s", again)
+	}
+
+	want := []string{"v1.4.2", "[v1.0.0, v1.2.0]"}
+	if got := retractionNames(readRetractions(root)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("readRetractions() = %q, want %q", got, want)
+	}
+}
+
+func TestRetractCommands(t *testing.T) {
+	tags := []string{"v1.4.1", "v1.4.2", "v1.3.0"}
+	got, err := retractCommands(tags, "", modfile.VersionInterval{Low: "v1.4.2", High: "v1.4.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"git commit -m 'go.mod: retract v1.4.2' go.mod",
+		"git tag v1.4.3",
+		"git push --tags",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("retractCommands() = %q, want %q", got, want)
+	}
+
+	got, err = retractCommands(tags, "", modfile.VersionInterval{Low: "v1.5.0", High: "v1.5.0"})
+	if err != nil || len(got) != 4 || got[0] != "# v1.5.0 is not tagged in this repository" {
+		t.Fatalf("retractCommands(untagged) = %q, %v, want a comment first", got, err)
+	}
+
+	if got, err := retractCommands(nil, "", modfile.VersionInterval{Low: "v1.0.0", High: "v1.0.0"}); err == nil {
+		t.Fatalf("retractCommands() without tags = %q, want an error", got)
+	}
+}
+
+func TestRenderTablesRetracted(t *testing.T) {
+	refs := versionRefs{"example.com/app": {"example.com/lib": "v1.4.2"}}
+	tags := latestTags{"example.com/lib": "v1.4.3"}
+	lib := moduleInfo{
+		Name:        "example.com/lib",
+		Path:        "./lib",
+		Latest:      "v1.4.3",
+		Retractions: []modfile.VersionInterval{{Low: "v1.4.2", High: "v1.4.2"}},
+		UsedBy:      []string{"example.com/app"},
+		GitState:    &components.Git{BranchName: "main"},
+	}
+	lib.Usage, lib.Outdated = buildUsage(refs, tags, lib)
+	if len(lib.Usage.UsedBy) != 1 || lib.Usage.UsedBy[0].Retracted != "v1.4.2" {
+		t.Fatalf("buildUsage() = %#v, want the dependent requiring a retracted version", lib.Usage)
+	}
+
+	var out strings.Builder
+	renderTables(&out, []moduleInfo{lib}, &Options{Verbose: true, Columns: []string{"module"}}, false)
+	got := out.String()
+	for _, want := range []string{
+		"retracted v1.4.2",
+		"app requires example.com/lib v1.4.2, which is retracted",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("renderTables() =\n%s\nwant it to hold %q", got, want)
+		}
+	}
+}
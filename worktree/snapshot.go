@@ -0,0 +1,300 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// commandSnapshot saves the collected workspace state under a name, or
+// compares two saved states, or a saved one and the current one.
+const commandSnapshot = "snapshot"
+
+// Snapshot subcommands.
+const (
+	snapshotSave = "save"
+	snapshotDiff = "diff"
+)
+
+// snapshotCurrent names the current workspace state in a diff.
+const snapshotCurrent = "now"
+
+// snapshot is the workspace state saved by "worktree snapshot save".
+type snapshot struct {
+	Name    string                    `json:"name"`
+	Root    string                    `json:"root"`
+	Taken   time.Time                 `json:"taken"`
+	Modules map[string]snapshotModule `json:"modules"`
+}
+
+// snapshotModule is the saved state of one module.
+type snapshotModule struct {
+	Path     string            `json:"path"`
+	Latest   string            `json:"latest,omitempty"`
+	Branch   string            `json:"branch,omitempty"`
+	Ahead    int               `json:"ahead,omitempty"`
+	Unpushed int               `json:"unpushed,omitempty"`
+	Behind   int               `json:"behind,omitempty"`
+	Requires map[string]string `json:"requires,omitempty"`
+}
+
+// takeSnapshot records the state of the modules: the latest tag, the branch
+// and its counts, and every requirement of the go.mod.
+func takeSnapshot(name, root string, modules []moduleInfo, now time.Time) snapshot {
+	s := snapshot{Name: name, Root: root, Taken: now, Modules: make(map[string]snapshotModule)}
+	for _, m := range modules {
+		sm := snapshotModule{Path: m.Path, Latest: m.Latest}
+		if g := m.GitState; g != nil {
+			sm.Branch, sm.Ahead, sm.Unpushed, sm.Behind = g.BranchName, g.Ahead, g.Unpushed, g.Behind
+		}
+		if reqs, err := readRequiresVersioned(m.Path); err == nil && len(reqs) > 0 {
+			sm.Requires = make(map[string]string, len(reqs))
+			for _, r := range reqs {
+				sm.Requires[r.path] = r.version
+			}
+		}
+		s.Modules[m.Name] = sm
+	}
+	return s
+}
+
+// snapshotDir returns the directory holding the snapshots of the workspace
+// at root, below the user cache directory, one per scan root.
+func snapshotDir(root string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256([]byte(abs))
+	return filepath.Join(cache, "worktree", "snapshots", hex.EncodeToString(h[:8])), nil
+}
+
+// checkSnapshotName refuses a name that is not a plain file name.
+func checkSnapshotName(name string) error {
+	if name == "" || name == snapshotCurrent || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
+
+// saveSnapshot writes the snapshot to dir, replacing one of the same name.
+func saveSnapshot(dir string, s snapshot) error {
+	if err := checkSnapshotName(s.Name); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, s.Name+".json"), append(data, '\n'), 0o644)
+}
+
+// loadSnapshot reads the snapshot saved under name.
+func loadSnapshot(dir, name string) (snapshot, error) {
+	if err := checkSnapshotName(name); err != nil {
+		return snapshot{}, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if os.IsNotExist(err) {
+		return snapshot{}, fmt.Errorf("no snapshot %q, saved are: %s", name, strings.Join(snapshotNames(dir), ", "))
+	}
+	if err != nil {
+		return snapshot{}, err
+	}
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return snapshot{}, fmt.Errorf("snapshot %q: %w", name, err)
+	}
+	return s, nil
+}
+
+// snapshotNames lists the saved snapshots, oldest first.
+func snapshotNames(dir string) []string {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	var saved []snapshot
+	for _, path := range paths {
+		if s, err := loadSnapshot(dir, strings.TrimSuffix(filepath.Base(path), ".json")); err == nil {
+			saved = append(saved, s)
+		}
+	}
+	sort.SliceStable(saved, func(i, j int) bool { return saved[i].Taken.Before(saved[j].Taken) })
+	names := make([]string, 0, len(saved))
+	for _, s := range saved {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// defaultSnapshotName names a snapshot saved without a name by its day, so
+// saving every morning keeps one per day.
+func defaultSnapshotName(now time.Time) string {
+	return now.Format("2006-01-02")
+}
+
+// runSnapshot saves or compares snapshots of the workspace at root. The
+// current state is made of modules, which is nil when the workspace was not
+// scanned because both sides of a diff are saved snapshots.
+func runSnapshot(w io.Writer, root string, opts *Options, modules []moduleInfo, styled bool) error {
+	dir, err := snapshotDir(root)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	args := opts.SnapshotArgs
+
+	switch opts.Snapshot {
+	case snapshotSave:
+		name := defaultSnapshotName(now)
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if err := saveSnapshot(dir, takeSnapshot(name, root, modules, now)); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Saved snapshot %s of %d modules.\n", name, len(modules))
+		return nil
+
+	case snapshotDiff:
+		var from, to snapshot
+		switch len(args) {
+		case 0:
+			names := snapshotNames(dir)
+			if len(names) == 0 {
+				return fmt.Errorf("no snapshots saved, run worktree snapshot save first")
+			}
+			if from, err = loadSnapshot(dir, names[len(names)-1]); err != nil {
+				return err
+			}
+		default:
+			if from, err = loadSnapshot(dir, args[0]); err != nil {
+				return err
+			}
+		}
+		if len(args) > 1 && args[1] != snapshotCurrent {
+			if to, err = loadSnapshot(dir, args[1]); err != nil {
+				return err
+			}
+		} else {
+			to = takeSnapshot(snapshotCurrent, root, modules, now)
+		}
+		renderSnapshotDiff(w, from, to, styled)
+		return nil
+	}
+	return fmt.Errorf("unknown snapshot command %q, want save or diff", opts.Snapshot)
+}
+
+// snapshotChanges lists what changed for one module between two snapshots:
+// whether it was added or removed, a new tag, a branch switch, the branch
+// counts, and the go.mod requirements that moved.
+func snapshotChanges(before, after snapshotModule, inBefore, inAfter bool, styled bool) *status {
+	s := &status{styled: styled}
+	switch {
+	case !inBefore:
+		s.add(components.ColorGreen, "added")
+	case !inAfter:
+		s.add(components.ColorSeparator, "removed")
+		return s
+	}
+
+	switch {
+	case before.Latest == after.Latest:
+	case before.Latest == "":
+		s.add(components.ColorGreen, "tag %s", after.Latest)
+	default:
+		s.add(components.ColorGreen, "tag %s → %s", before.Latest, after.Latest)
+	}
+	if inBefore && before.Branch != after.Branch {
+		s.add(components.ColorAmber, "branch %s → %s", before.Branch, after.Branch)
+	}
+	for _, count := range []struct {
+		name          string
+		before, after int
+	}{
+		{"ahead", before.Ahead, after.Ahead},
+		{"unpushed", before.Unpushed, after.Unpushed},
+		{"behind", before.Behind, after.Behind},
+	} {
+		if inBefore && count.before != count.after {
+			s.add(components.ColorTeal, "%s %d → %d", count.name, count.before, count.after)
+		}
+	}
+	if inBefore {
+		for _, c := range diffRequires(requireList(before.Requires), requireList(after.Requires)) {
+			s.add(c.Color(), "%s", c)
+		}
+	}
+	return s
+}
+
+// requireList turns saved requirements back into go.mod requirements.
+func requireList(requires map[string]string) []requireInfo {
+	reqs := make([]requireInfo, 0, len(requires))
+	for path, version := range requires {
+		reqs = append(reqs, requireInfo{path: path, version: version})
+	}
+	return reqs
+}
+
+// renderSnapshotDiff writes a table of the modules that changed between two
+// snapshots, with what changed for each.
+func renderSnapshotDiff(w io.Writer, from, to snapshot, styled bool) {
+	names := make([]string, 0, len(from.Modules)+len(to.Modules))
+	for name := range from.Modules {
+		names = append(names, name)
+	}
+	for name := range to.Modules {
+		if _, ok := from.Modules[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var rows [][]string
+	for _, name := range names {
+		before, inBefore := from.Modules[name]
+		after, inAfter := to.Modules[name]
+		s := snapshotChanges(before, after, inBefore, inAfter, styled)
+		if s.empty() {
+			continue
+		}
+		path := after.Path
+		if !inAfter {
+			path = before.Path
+		}
+		rows = append(rows, []string{path, components.ShortPath(name), s.String()})
+	}
+
+	span := snapshotLabel(from) + " → " + snapshotLabel(to)
+	if len(rows) == 0 {
+		fmt.Fprintf(w, "No changes from %s.\n", span)
+		return
+	}
+	writeSimpleTable(w, []string{"Path", "Module", "Changes"}, rows, styled)
+
+	borderColor, reset := "", ""
+	if styled {
+		borderColor, reset = components.ColorBorder, components.ColorReset
+	}
+	fmt.Fprintf(w, "%s%d modules changed from %s%s\n", borderColor, len(rows), span, reset)
+}
+
+// snapshotLabel names a snapshot with the time it was taken.
+func snapshotLabel(s snapshot) string {
+	return s.Name + " (" + s.Taken.Format("2006-01-02 15:04") + ")"
+}
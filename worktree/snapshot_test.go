@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "app", "go.mod"), "module example.com/app\n\ngo 1.26\n\nrequire (\n\texample.com/lib v1.0.0\n\tgolang.org/x/mod v0.20.0\n)\n")
+	chdir(t, root)
+
+	modules := []moduleInfo{{
+		Name:     "example.com/app",
+		Path:     "./app",
+		Latest:   "v0.3.0",
+		GitState: &components.Git{BranchName: "main", Ahead: 2, Unpushed: 1},
+	}}
+	taken := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	s := takeSnapshot(defaultSnapshotName(taken), root, modules, taken)
+	want := snapshotModule{
+		Path:     "./app",
+		Latest:   "v0.3.0",
+		Branch:   "main",
+		Ahead:    2,
+		Unpushed: 1,
+		Requires: map[string]string{"example.com/lib": "v1.0.0", "golang.org/x/mod": "v0.20.0"},
+	}
+	if s.Name != "2026-10-18" || !reflect.DeepEqual(s.Modules["example.com/app"], want) {
+		t.Fatalf("takeSnapshot() = %+v, want %+v", s, want)
+	}
+
+	dir, err := snapshotDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saveSnapshot(dir, s); err != nil {
+		t.Fatal(err)
+	}
+	later := s
+	later.Name, later.Taken = "release", taken.Add(time.Hour)
+	if err := saveSnapshot(dir, later); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadSnapshot(dir, "2026-10-18")
+	if err != nil || !reflect.DeepEqual(loaded.Modules, s.Modules) || !loaded.Taken.Equal(taken) {
+		t.Fatalf("loadSnapshot() = %+v, %v, want the saved snapshot", loaded, err)
+	}
+	if got := snapshotNames(dir); !reflect.DeepEqual(got, []string{"2026-10-18", "release"}) {
+		t.Fatalf("snapshotNames() = %q, want them oldest first", got)
+	}
+	if _, err := loadSnapshot(dir, "missing"); err == nil || !strings.Contains(err.Error(), "2026-10-18, release") {
+		t.Fatalf("loadSnapshot(missing) error = %v, want the saved names", err)
+	}
+	for _, name := range []string{"", "now", "../escape", ".hidden"} {
+		if err := saveSnapshot(dir, snapshot{Name: name}); err == nil {
+			t.Errorf("saveSnapshot(%q) succeeded", name)
+		}
+	}
+}
+
+func TestRenderSnapshotDiff(t *testing.T) {
+	from := snapshot{Name: "monday", Taken: time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), Modules: map[string]snapshotModule{
+		"example.com/app": {Path: "./app", Latest: "v0.3.0", Branch: "main", Ahead: 2, Requires: map[string]string{"example.com/lib": "v1.0.0", "golang.org/x/mod": "v0.20.0"}},
+		"example.com/lib": {Path: "./lib", Latest: "v1.0.0", Branch: "main"},
+		"example.com/old": {Path: "./old", Branch: "main"},
+	}}
+	to := snapshot{Name: "now", Taken: time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC), Modules: map[string]snapshotModule{
+		"example.com/app": {Path: "./app", Latest: "v0.3.0", Branch: "feature", Ahead: 3, Requires: map[string]string{"example.com/lib": "v1.1.0", "golang.org/x/sync": "v0.8.0"}},
+		"example.com/lib": {Path: "./lib", Latest: "v1.1.0", Branch: "main"},
+		"example.com/new": {Path: "./new", Latest: "v0.1.0", Branch: "main"},
+	}}
+
+	var out strings.Builder
+	renderSnapshotDiff(&out, from, to, false)
+	want := "| Path | Module | Changes |\n" +
+		"| --- | --- | --- |\n" +
+		"| ./app | example.com/app | branch main → feature<br>ahead 2 → 3<br>example.com/lib v1.0.0 → v1.1.0<br>- golang.org/x/mod v0.20.0<br>+ golang.org/x/sync v0.8.0 |\n" +
+		"| ./lib | example.com/lib | tag v1.0.0 → v1.1.0 |\n" +
+		"| ./new | example.com/new | added<br>tag v0.1.0 |\n" +
+		"| ./old | example.com/old | removed |\n" +
+		"4 modules changed from monday (2026-10-19 08:00) → now (2026-10-20 08:00)\n"
+	if got := out.String(); got != want {
+		t.Fatalf("renderSnapshotDiff() =\n%s\nwant:\n%s", got, want)
+	}
+
+	out.Reset()
+	renderSnapshotDiff(&out, from, from, false)
+	if got := out.String(); !strings.HasPrefix(got, "No changes from monday") {
+		t.Fatalf("renderSnapshotDiff(same) = %q, want no changes", got)
+	}
+}
+
+func TestRunSnapshotDiffAgainstLatest(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	chdir(t, root)
+
+	modules := []moduleInfo{{Name: "example.com/lib", Path: "./lib", Latest: "v1.0.0", GitState: &components.Git{BranchName: "main"}}}
+	var out strings.Builder
+	if err := runSnapshot(&out, root, &Options{Snapshot: snapshotDiff}, modules, false); err == nil {
+		t.Fatal("runSnapshot(diff) without snapshots succeeded")
+	}
+	if err := runSnapshot(&out, root, &Options{Snapshot: snapshotSave, SnapshotArgs: []string{"before"}}, modules, false); err != nil {
+		t.Fatal(err)
+	}
+
+	modules[0].Latest = "v1.0.1"
+	out.Reset()
+	if err := runSnapshot(&out, root, &Options{Snapshot: snapshotDiff}, modules, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "| ./lib | example.com/lib | tag v1.0.0 → v1.0.1 |") {
+		t.Fatalf("runSnapshot(diff) =\n%s\nwant the new tag", got)
+	}
+}
+
+func TestParseOptionsSnapshot(t *testing.T) {
+	originalArgs := os.Args
+	originalFlags := flag.CommandLine
+	defer func() {
+		os.Args = originalArgs
+		flag.CommandLine = originalFlags
+	}()
+	os.Args = []string{"worktree", "snapshot", "diff", "monday", "now"}
+	flag.CommandLine = flag.NewFlagSet("worktree", flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseOptions()
+	if opts.Snapshot != snapshotDiff || !reflect.DeepEqual(opts.SnapshotArgs, []string{"monday", "now"}) || opts.FilterArg != "" {
+		t.Fatalf("ParseOptions() = %#v, want a diff of monday and now", opts)
+	}
+}
@@ -18,17 +18,34 @@ type streamTable struct {
 	widths  []int
 	styled  bool
 	pending []string
+
+	// last is the width the lines of the last column are wrapped to, 0
+	// when the table is not fitted to a width.
+	last int
 }
 
 // newStreamTable writes the table header and returns the writer for its rows.
 // The widths hold the display width of each leading column; the last column is
-// unbounded and its width is only used for the header rule.
-func newStreamTable(w io.Writer, headers []string, widths []int, styled bool) *streamTable {
+// unbounded and its width is only used for the header rule. A limit above 0
+// fits the table to that width: the leading columns are narrowed when the last
+// would get fewer than streamMinWidth cells, and its lines are wrapped.
+func newStreamTable(w io.Writer, headers []string, widths []int, limit int, styled bool) *streamTable {
 	t := &streamTable{w: w, numCols: len(headers), widths: widths, styled: styled}
 	if !styled {
 		writeMarkdownHeader(w, headers)
 		return t
 	}
+	if limit > 0 {
+		leading := widths[:len(widths)-1]
+		mins := make([]int, len(leading))
+		for i, width := range leading {
+			mins[i] = minColumnWidth(headers[i], width)
+		}
+		leading = shrinkWidths(leading, mins, limit-streamMinWidth-1)
+		t.last = max(limit-tableWidth(leading)-1, streamMinWidth)
+		t.widths = append(leading, min(widths[len(widths)-1], t.last))
+		widths = t.widths
+	}
 	cells := make([]string, 0, len(headers))
 	for _, header := range headers {
 		cells = append(cells, components.ColorHeader+header+components.ColorReset)
@@ -44,6 +61,13 @@ func newStreamTable(w io.Writer, headers []string, widths []int, styled bool) *s
 func (t *streamTable) start(cells ...string) {
 	t.pending = cells
 	if t.styled {
+		if t.last > 0 {
+			fitted := make([]string, len(cells))
+			for i, cell := range cells {
+				fitted[i] = truncateLine(cell, t.widths[i])
+			}
+			cells = fitted
+		}
 		fmt.Fprint(t.w, openRowPrefix(cells, t.widths))
 	}
 }
@@ -57,7 +81,15 @@ func (t *streamTable) finish(value string) {
 		return
 	}
 	blanks := make([]string, len(t.pending))
-	for i, line := range components.Cell(strings.Split(strings.TrimSpace(value), "\n")) {
+	lines := components.Cell(strings.Split(strings.TrimSpace(value), "\n"))
+	if t.last > 0 {
+		var wrapped components.Cell
+		for _, line := range lines {
+			wrapped = append(wrapped, wrapLine(line, t.last)...)
+		}
+		lines = wrapped
+	}
+	for i, line := range lines {
 		if i > 0 {
 			fmt.Fprint(t.w, openRowPrefix(blanks, t.widths))
 		}
@@ -11,7 +11,7 @@ import (
 
 func TestStreamTableWritesRowsAsTheyFinish(t *testing.T) {
 	var output bytes.Buffer
-	table := newStreamTable(&output, []string{"Path", "Update status"}, []int{8, 13}, true)
+	table := newStreamTable(&output, []string{"Path", "Update status"}, []int{8, 13}, 0, true)
 
 	table.start("./alpha")
 	started := ansi.Strip(output.String())
@@ -39,7 +39,7 @@ func TestStreamTableWritesRowsAsTheyFinish(t *testing.T) {
 
 func TestStreamTableStylesHeader(t *testing.T) {
 	var output bytes.Buffer
-	table := newStreamTable(&output, []string{"Path", "Update status"}, []int{4, 13}, true)
+	table := newStreamTable(&output, []string{"Path", "Update status"}, []int{4, 13}, 0, true)
 	table.close()
 
 	got := output.String()
@@ -56,7 +56,7 @@ func TestStreamTableStylesHeader(t *testing.T) {
 
 func TestStreamTableMarkdown(t *testing.T) {
 	var output bytes.Buffer
-	table := newStreamTable(&output, []string{"Path", "Update status"}, []int{4, 13}, false)
+	table := newStreamTable(&output, []string{"Path", "Update status"}, []int{4, 13}, 0, false)
 	table.start("./alpha")
 	table.finish(components.ColorAmber + "example.com/lib v1.0.0 → v1.1.0" + components.ColorReset + "\nDone | tidy")
 	table.close()
@@ -68,3 +68,24 @@ func TestStreamTableMarkdown(t *testing.T) {
 		t.Fatalf("streamTable markdown =\n%s\nwant:\n%s", got, want)
 	}
 }
+
+func TestStreamTableFitsWidth(t *testing.T) {
+	var output bytes.Buffer
+	table := newStreamTable(&output, []string{"Path", "Module", "Update status"}, []int{30, 20, 13}, 50, true)
+	table.start("./services/payments-gateway", "acme/payments-gateway")
+	table.finish("example.com/lib v1.0.0 → v1.1.0\n+ example.com/new v0.1.0")
+	table.close()
+
+	want := "" +
+		"╭─────────────┬─────────────┬───────────────\n" +
+		"│ Path        │ Module      │ Update status\n" +
+		"├─────────────┼─────────────┼───────────────\n" +
+		"│ ./services… │ acme/payme… │ example.com/lib\n" +
+		"│             │             │ v1.0.0 → v1.1.0\n" +
+		"│             │             │ + example.com/new\n" +
+		"│             │             │ v0.1.0\n" +
+		"╰─────────────┴─────────────┴───────────────\n"
+	if got := ansi.Strip(output.String()); got != want {
+		t.Fatalf("streamTable rendered:\n%s\nwant:\n%s", got, want)
+	}
+}
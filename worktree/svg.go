@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// The layout of --svg: a monospace grid of cells on a dark background, sized
+// like a terminal at 14px.
+const (
+	svgFontSize   = 14
+	svgCellWidth  = 8.4
+	svgLineHeight = 18
+	svgPadding    = 16
+	svgFont       = `ui-monospace, "DejaVu Sans Mono", Menlo, Consolas, monospace`
+	svgBackground = "#1c1c1c"
+	svgForeground = "#d0d0d0"
+)
+
+// svgTheme is the theme --svg renders with, the one svgBackground is chosen
+// for, so an image comes out the same whatever the theme and color mode of
+// the terminal it is made in.
+const svgTheme = "dark"
+
+// useSVGPalette sets the colors of svgTheme, without the palette section of
+// the configuration over them.
+func useSVGPalette() error {
+	palette, ok := components.Theme(svgTheme)
+	if !ok {
+		return fmt.Errorf("unknown theme %q", svgTheme)
+	}
+	return components.SetPalette(palette)
+}
+
+// svgRun is a stretch of text in one style, starting at a terminal column.
+type svgRun struct {
+	column int
+	text   string
+	color  string
+	bold   bool
+}
+
+// xtermColor returns the RGB color of an xterm-256 palette index: the 16
+// system colors, the 6×6×6 color cube and the 24 step gray ramp.
+func xtermColor(n int) string {
+	system := []string{
+		"#000000", "#800000", "#008000", "#808000", "#000080", "#800080", "#008080", "#c0c0c0",
+		"#808080", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff",
+	}
+	switch {
+	case n < 0 || n > 255:
+		return svgForeground
+	case n < 16:
+		return system[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	}
+	gray := 8 + (n-232)*10
+	return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+}
+
+// parseANSILine splits a line of styled output into runs of text, reading the
+// SGR sequences the components write: reset, bold, and xterm-256, RGB and
+// system foreground colors. Other escape sequences are dropped.
+func parseANSILine(line string) []svgRun {
+	var runs []svgRun
+	var text strings.Builder
+	color, bold := svgForeground, false
+	column, start := 0, 0
+	flush := func() {
+		if text.Len() > 0 {
+			runs = append(runs, svgRun{column: start, text: text.String(), color: color, bold: bold})
+			text.Reset()
+		}
+		start = column
+	}
+
+	for i := 0; i < len(line); {
+		if line[i] != '\033' {
+			r := line[i:]
+			end := 1
+			for end < len(r) && r[end] != '\033' {
+				end++
+			}
+			text.WriteString(r[:end])
+			column += ansi.StringWidth(r[:end])
+			i += end
+			continue
+		}
+		if i+1 >= len(line) || line[i+1] != '[' {
+			i++
+			continue
+		}
+		end := i + 2
+		for end < len(line) && (line[end] < 0x40 || line[end] > 0x7e) {
+			end++
+		}
+		if end >= len(line) {
+			break
+		}
+		if line[end] == 'm' {
+			flush()
+			params := strings.Split(line[i+2:end], ";")
+			for p := 0; p < len(params); p++ {
+				switch code, _ := strconv.Atoi(params[p]); {
+				case code == 0:
+					color, bold = svgForeground, false
+				case code == 1:
+					bold = true
+				case code == 22:
+					bold = false
+				case code == 39:
+					color = svgForeground
+				case code >= 30 && code <= 37:
+					color = xtermColor(code - 30)
+				case code >= 90 && code <= 97:
+					color = xtermColor(code - 90 + 8)
+				case code == 38 && p+2 < len(params) && params[p+1] == "5":
+					n, _ := strconv.Atoi(params[p+2])
+					color = xtermColor(n)
+					p += 2
+				case code == 38 && p+4 < len(params) && params[p+1] == "2":
+					r, _ := strconv.Atoi(params[p+2])
+					g, _ := strconv.Atoi(params[p+3])
+					b, _ := strconv.Atoi(params[p+4])
+					color = fmt.Sprintf("#%02x%02x%02x", r, g, b)
+					p += 4
+				}
+			}
+		}
+		i = end + 1
+	}
+	flush()
+	return runs
+}
+
+// renderSVG writes styled terminal output as an SVG image, each run of text
+// placed at its terminal column so box drawing lines up whatever the font's
+// advance width.
+func renderSVG(w io.Writer, output string) error {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	columns := 0
+	for _, line := range lines {
+		columns = max(columns, ansi.StringWidth(line))
+	}
+	width := math.Round((float64(columns)*svgCellWidth+2*svgPadding)*10) / 10
+	height := len(lines)*svgLineHeight + 2*svgPadding
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%g\" height=\"%d\" viewBox=\"0 0 %g %d\">\n", width, height, width, height)
+	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" rx=\"6\" fill=\"%s\"/>\n", svgBackground)
+	fmt.Fprintf(&b, "<g font-family=\"%s\" font-size=\"%d\" xml:space=\"preserve\">\n", escapeXML(svgFont), svgFontSize)
+	for i, line := range lines {
+		runs := parseANSILine(line)
+		if len(runs) == 0 {
+			continue
+		}
+		y := svgPadding + i*svgLineHeight + svgFontSize
+		fmt.Fprintf(&b, "<text y=\"%d\">", y)
+		for _, run := range runs {
+			if strings.TrimSpace(run.text) == "" {
+				continue
+			}
+			weight := ""
+			if run.bold {
+				weight = ` font-weight="bold"`
+			}
+			x := math.Round((svgPadding+float64(run.column)*svgCellWidth)*10) / 10
+			fmt.Fprintf(&b, "<tspan x=\"%g\" fill=\"%s\"%s>%s</tspan>", x, run.color, weight, escapeXML(run.text))
+		}
+		b.WriteString("</text>\n")
+	}
+	b.WriteString("</g>\n</svg>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// escapeXML escapes text for an SVG attribute or element.
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// writeSVGFile renders styled output to the SVG file at path.
+func writeSVGFile(path, output string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := renderSVG(f, output); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
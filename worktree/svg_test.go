@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+func TestXtermColor(t *testing.T) {
+	tests := map[int]string{
+		1:   "#800000",
+		15:  "#ffffff",
+		16:  "#000000",
+		114: "#87d787",
+		214: "#ffaf00",
+		231: "#ffffff",
+		232: "#080808",
+		238: "#444444",
+		255: "#eeeeee",
+	}
+	for n, want := range tests {
+		if got := xtermColor(n); got != want {
+			t.Errorf("xtermColor(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestParseANSILine(t *testing.T) {
+	line := components.ColorSeparator + "│" + components.ColorReset + " " +
+		components.ColorGreen + "▲" + components.ColorReset + " lib " +
+		"\033[1;38;5;214mv1.2.0\033[0m"
+	want := []svgRun{
+		{column: 0, text: "│", color: "#444444"},
+		{column: 1, text: " ", color: svgForeground},
+		{column: 2, text: "▲", color: "#87d787"},
+		{column: 3, text: " lib ", color: svgForeground},
+		{column: 8, text: "v1.2.0", color: "#ffaf00", bold: true},
+	}
+	if got := parseANSILine(line); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseANSILine() =\n%+v\nwant:\n%+v", got, want)
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	modules := []moduleInfo{
+		{Name: "example.com/app", Uses: []string{"example.com/lib"}},
+		{Name: "example.com/lib"},
+	}
+	var table strings.Builder
+	renderDependencyMatrix(&table, modules, versionRefs{"example.com/app": {"example.com/lib": "v1.0.0"}}, latestTags{"example.com/lib": "v1.1.0"}, false, true)
+
+	var out strings.Builder
+	if err := renderSVG(&out, table.String()); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+
+	// The image is well formed XML, sized to the widest line.
+	decoder := xml.NewDecoder(strings.NewReader(got))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if err.Error() != "EOF" {
+				t.Fatalf("renderSVG() is not well formed: %v\n%s", err, got)
+			}
+			break
+		}
+	}
+	if !strings.HasPrefix(got, `<svg xmlns="http://www.w3.org/2000/svg" width="`) {
+		t.Fatalf("renderSVG() = %s, want an svg element", got)
+	}
+	// "0 ahead, 0 with local changes, 1 deps out of date." is the widest line.
+	if want := `width="452" height="140"`; !strings.Contains(got, want) {
+		t.Fatalf("renderSVG() does not hold %s:\n%s", want, got)
+	}
+	if want := `<tspan x="116.8" fill="#ffd700">▲*</tspan>`; !strings.Contains(got, want) {
+		t.Fatalf("renderSVG() does not place the outdated mark at its column, want %s:\n%s", want, got)
+	}
+	if strings.Contains(got, "\033") {
+		t.Fatalf("renderSVG() kept escape sequences:\n%q", got)
+	}
+}
+
+// TestUseSVGPalette checks --svg renders in the dark theme it paints the
+// background for, whether color is off or a light theme is configured.
+func TestUseSVGPalette(t *testing.T) {
+	dark, _ := components.Theme(svgTheme)
+	t.Cleanup(func() { components.SetPalette(dark) })
+
+	light, _ := components.Theme("light")
+	components.SetPalette(light)
+	components.DisableColor()
+	if err := useSVGPalette(); err != nil {
+		t.Fatal(err)
+	}
+	if components.ColorWhite != dark["white"] || components.ColorReset == "" {
+		t.Fatalf("ColorWhite = %q, ColorReset = %q, want the dark theme", components.ColorWhite, components.ColorReset)
+	}
+	if runs := parseANSILine(components.ColorGreen + "▲" + components.ColorReset); len(runs) != 1 || runs[0].color != "#87d787" {
+		t.Fatalf("parseANSILine() = %+v, want the dark green", runs)
+	}
+}
@@ -11,7 +11,12 @@ import (
 	"github.com/titpetric/tools/worktree/components"
 )
 
+// supportsANSI reports whether output to w is styled: a terminal table
+// rather than markdown. --color=always and FORCE_COLOR style any output.
 func supportsANSI(w io.Writer) bool {
+	if forceColor {
+		return true
+	}
 	f, ok := w.(*os.File)
 	if !ok || os.Getenv("TERM") == "dumb" {
 		return false
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// gitTagPrefix returns the prefix of the release tags of the module at dir,
+// as the go command reads them: a module in a subdirectory of its git
+// repository is tagged with that subdirectory, such as "gofsck/v1.2.3",
+// and a module at the root is tagged bare. The major version subdirectory of
+// a module path ending in /vN is not part of the prefix, so the module in
+// "gofsck/v2" is tagged "gofsck/v2.0.0".
+func gitTagPrefix(dir, modPath string) string {
+	cmd := exec.Command("git", "rev-parse", "--show-prefix")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	prefix := strings.TrimSpace(string(out))
+	if _, major, ok := module.SplitPathVersion(modPath); ok && strings.HasPrefix(major, "/") {
+		prefix = strings.TrimSuffix(prefix, major[1:]+"/")
+	}
+	return prefix
+}
+
+// pathMajor returns the major version suffix of a module path, such as "/v2",
+// empty for a v0 or v1 module.
+func pathMajor(modPath string) string {
+	_, major, _ := module.SplitPathVersion(modPath)
+	return major
+}
+
+// moduleVersions returns the versions of the tags carrying prefix, with the
+// prefix cut, that belong to a module with the major version suffix major:
+// v0 and v1 for none, v2 for "/v2". With no prefix, the tags of nested
+// modules are left out.
+func moduleVersions(tags []string, prefix, major string) []string {
+	var versions []string
+	for _, tag := range tags {
+		version, ok := strings.CutPrefix(tag, prefix)
+		if ok && !strings.Contains(version, "/") && module.CheckPathMajor(version, major) == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// modulePathspec returns the git pathspec of the module in dir: the
+// directory, without the nested modules below it, whose commits are theirs.
+// The nested modules are the go.mod files git tracks below dir, so ignored
+// and untracked directories are never walked.
+func modulePathspec(dir string) []string {
+	var dirs []string
+	for _, file := range strings.Split(gitOutput(dir, "ls-files", "--", "*/go.mod"), "\n") {
+		if file != "" {
+			dirs = append(dirs, strings.TrimSuffix(file, "/go.mod"))
+		}
+	}
+	// Sorted, a module comes before the modules nested in it, which its
+	// exclusion already covers.
+	sort.Strings(dirs)
+	spec := []string{"--", "."}
+	var nested []string
+	for _, path := range dirs {
+		if !slices.ContainsFunc(nested, func(parent string) bool { return strings.HasPrefix(path, parent+"/") }) {
+			nested = append(nested, path)
+			spec = append(spec, ":(exclude)"+path)
+		}
+	}
+	return spec
+}
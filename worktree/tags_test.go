@@ -0,0 +1,146 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// taggedRepo writes a git repository with a root module and two nested ones,
+// gofsck and its major version subdirectory gofsck/v2, each tagged with its
+// prefix, and a commit to gofsck after its release.
+func taggedRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/tools\n\ngo 1.26\n")
+	writeTestFile(t, filepath.Join(root, "gofsck", "go.mod"), "module example.com/tools/gofsck\n\ngo 1.26\n")
+	writeTestFile(t, filepath.Join(root, "gofsck", "v2", "go.mod"), "module example.com/tools/gofsck/v2\n\ngo 1.26\n")
+
+	commit := func(message string) {
+		runGit(t, root, "add", "-A")
+		runGit(t, root, "-c", "user.email=test@example.com", "-c", "user.name=test", "commit", "--quiet", "-m", message)
+	}
+	runGit(t, root, "init", "--quiet")
+	commit("init")
+	runGit(t, root, "tag", "v0.3.0")
+	runGit(t, root, "tag", "gofsck/v1.2.3")
+	runGit(t, root, "tag", "gofsck/v1.10.0")
+	runGit(t, root, "tag", "gofsck/v2.0.0")
+	writeTestFile(t, filepath.Join(root, "gofsck", "main.go"), "package main\n")
+	commit("gofsck: add main")
+	return root
+}
+
+func TestGitTagPrefix(t *testing.T) {
+	root := taggedRepo(t)
+	tests := map[string][2]string{
+		"root":   {".", "example.com/tools"},
+		"nested": {"gofsck", "example.com/tools/gofsck"},
+		"major":  {filepath.Join("gofsck", "v2"), "example.com/tools/gofsck/v2"},
+	}
+	want := map[string]string{"root": "", "nested": "gofsck/", "major": "gofsck/"}
+	for name, tt := range tests {
+		if got := gitTagPrefix(filepath.Join(root, tt[0]), tt[1]); got != want[name] {
+			t.Errorf("gitTagPrefix(%s) = %q, want %q", name, got, want[name])
+		}
+	}
+}
+
+func TestModuleVersions(t *testing.T) {
+	tags := []string{"v0.3.0", "v2.1.0", "gofsck/v1.2.3", "gofsck/v2.0.0", "gofsck/cmd/v0.1.0", "other/v1.0.0", "gofsck/latest"}
+	tests := []struct {
+		prefix, major string
+		want          []string
+	}{
+		{"", "", []string{"v0.3.0"}},
+		{"", "/v2", []string{"v2.1.0"}},
+		{"gofsck/", "", []string{"v1.2.3"}},
+		{"gofsck/", "/v2", []string{"v2.0.0"}},
+	}
+	for _, tt := range tests {
+		if got := moduleVersions(tags, tt.prefix, tt.major); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("moduleVersions(%q, %q) = %q, want %q", tt.prefix, tt.major, got, tt.want)
+		}
+	}
+}
+
+func TestLatestGitTagPrefixed(t *testing.T) {
+	root := taggedRepo(t)
+	nested := filepath.Join(root, "gofsck")
+
+	if got := latestGitTag(root, "", ""); got != "v0.3.0" {
+		t.Fatalf("latestGitTag(root) = %q, want v0.3.0", got)
+	}
+	// gofsck/v2.0.0 belongs to the gofsck/v2 module, which shares the prefix.
+	if got := latestGitTag(nested, "gofsck/", ""); got != "v1.10.0" {
+		t.Fatalf("latestGitTag(gofsck) = %q, want v1.10.0", got)
+	}
+	if got := latestGitTag(filepath.Join(nested, "v2"), "gofsck/", "/v2"); got != "v2.0.0" {
+		t.Fatalf("latestGitTag(gofsck/v2) = %q, want v2.0.0", got)
+	}
+	// The commit after the release touches only gofsck outside v2.
+	if got := commitsSinceTag(nested, modulePathspec(nested), "gofsck/v1.10.0"); got != 1 {
+		t.Fatalf("commitsSinceTag(gofsck) = %d, want 1", got)
+	}
+	v2 := filepath.Join(nested, "v2")
+	if got := commitsSinceTag(v2, modulePathspec(v2), "gofsck/v2.0.0"); got != 0 {
+		t.Fatalf("commitsSinceTag(gofsck/v2) = %d, want 0", got)
+	}
+	// Nor is it the root module's, which holds gofsck.
+	spec := modulePathspec(root)
+	if got := commitsSinceTag(root, spec, "v0.3.0"); got != 0 {
+		t.Fatalf("commitsSinceTag(root) = %d, want 0", got)
+	}
+	if got := commitMessagesSinceTag(root, spec, "v0.3.0"); len(got) != 0 {
+		t.Fatalf("commitMessagesSinceTag(root) = %q, want none", got)
+	}
+	if a := readActivity(root, spec, "v0.3.0"); !a.Unreleased.IsZero() || a.Contributors != 0 {
+		t.Fatalf("readActivity(root) = %+v, want no unreleased commits", a)
+	}
+}
+
+func TestReleaseCommandsPrefixed(t *testing.T) {
+	root := taggedRepo(t)
+	tags, err := gitTags(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]string{
+		".":                           {"git tag v0.3.1", "git push --tags"},
+		"gofsck":                      {"git tag gofsck/v1.10.1", "git push --tags"},
+		filepath.Join("gofsck", "v2"): {"git tag gofsck/v2.0.1", "git push --tags"},
+	}
+	for dir, want := range tests {
+		prefix, versions := moduleTags(filepath.Join(root, dir), tags)
+		got, err := releaseCommands(versions, prefix, releasePatch)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("releaseCommands(%s) = %q, %v, want %q", dir, got, err, want)
+		}
+	}
+
+	// Without a go.mod the repository is tagged as a whole.
+	if prefix, versions := moduleTags(t.TempDir(), tags); prefix != "" || !reflect.DeepEqual(versions, tags) {
+		t.Fatalf("moduleTags(no go.mod) = %q, %q, want every tag", prefix, versions)
+	}
+}
+
+func TestModulePathspec(t *testing.T) {
+	root := taggedRepo(t)
+	writeTestFile(t, filepath.Join(root, "internal", "tools", "go.mod"), "module example.com/tools/internal/tools\n")
+	runGit(t, root, "add", "internal")
+	runGit(t, root, "-c", "user.email=test@example.com", "-c", "user.name=test", "commit", "--quiet", "-m", "add tools")
+	// A module git does not track, such as one in a module cache, has no
+	// commits to leave out.
+	writeTestFile(t, filepath.Join(root, ".cache", "mod", "go.mod"), "module example.com/cached\n")
+	writeTestFile(t, filepath.Join(root, "node_modules", "pkg", "go.mod"), "module example.com/pkg\n")
+
+	want := []string{"--", ".", ":(exclude)gofsck", ":(exclude)internal/tools"}
+	if got := modulePathspec(root); !reflect.DeepEqual(got, want) {
+		t.Fatalf("modulePathspec(root) = %q, want %q", got, want)
+	}
+	want = []string{"--", ".", ":(exclude)v2"}
+	if got := modulePathspec(filepath.Join(root, "gofsck")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("modulePathspec(gofsck) = %q, want %q", got, want)
+	}
+}
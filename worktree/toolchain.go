@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"go/version"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// toolchainNone is the --toolchain value that removes the directive, as in
+// "go get toolchain@none".
+const toolchainNone = "none"
+
+// parseToolchain validates a --toolchain flag value and returns it in the
+// form used by the toolchain directive: "go1.27.2". The "go" may be left out,
+// so both "1.27.2" and "go1.27.2" are valid, and "none" is kept as is.
+func parseToolchain(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == toolchainNone {
+		return value, nil
+	}
+	name := "go" + strings.TrimPrefix(value, "go")
+	if !modfile.ToolchainRE.MatchString(name) || !version.IsValid(name) {
+		return "", fmt.Errorf("invalid toolchain %q", value)
+	}
+	return name, nil
+}
+
+// toolchainName returns the directive a --toolchain value leaves in a file,
+// empty for none.
+func toolchainName(toolchain string) string {
+	if toolchain == toolchainNone {
+		return ""
+	}
+	return toolchain
+}
+
+// toolchainChange formats a toolchain directive change as a status line.
+func toolchainChange(before, after string) string {
+	switch {
+	case before == "":
+		return "+ toolchain " + after
+	case after == "":
+		return "- toolchain " + before
+	}
+	return "toolchain " + before + " → " + after
+}
+
+// toolchainBelowGo reports whether a toolchain directive names a release
+// older than the go directive beside it, which the go tool refuses to build
+// with. The "default" toolchain and an absent directive are never below.
+func toolchainBelowGo(toolchain, goVersion string) bool {
+	if toolchain == "" || goVersion == "" || !version.IsValid(toolchain) {
+		return false
+	}
+	return version.Compare(toolchain, "go"+goVersion) < 0
+}
+
+// setToolchain rewrites the toolchain directive of the go.mod in dir, or
+// removes it for none, returning the toolchain it replaced. The file is left
+// untouched when it already names the toolchain, and a toolchain below the
+// module's go directive is refused.
+func setToolchain(dir, toolchain string) (string, error) {
+	path := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	mod, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var before, goVersion string
+	if mod.Toolchain != nil {
+		before = mod.Toolchain.Name
+	}
+	if mod.Go != nil {
+		goVersion = mod.Go.Version
+	}
+	after := toolchainName(toolchain)
+	if before == after {
+		return before, nil
+	}
+	if toolchainBelowGo(after, goVersion) {
+		return before, fmt.Errorf("toolchain %s is below go %s", after, goVersion)
+	}
+	if after == "" {
+		mod.DropToolchainStmt()
+	} else if err := mod.AddToolchainStmt(after); err != nil {
+		return before, err
+	}
+	mod.Cleanup()
+	return before, writeModFile(path, mod.Syntax)
+}
+
+// setGoWorkToolchain rewrites the toolchain directive of the go.work file at
+// path, returning the toolchain it replaced.
+func setGoWorkToolchain(path, toolchain string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	work, err := modfile.ParseWork(path, data, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var before, goVersion string
+	if work.Toolchain != nil {
+		before = work.Toolchain.Name
+	}
+	if work.Go != nil {
+		goVersion = work.Go.Version
+	}
+	after := toolchainName(toolchain)
+	if before == after {
+		return before, nil
+	}
+	if toolchainBelowGo(after, goVersion) {
+		return before, fmt.Errorf("toolchain %s is below go %s", after, goVersion)
+	}
+	if after == "" {
+		work.DropToolchainStmt()
+	} else if err := work.AddToolchainStmt(after); err != nil {
+		return before, err
+	}
+	work.Cleanup()
+	return before, writeModFile(path, work.Syntax)
+}
+
+// readWorkToolchain returns the toolchain directive of the go.work file at
+// path, empty when it has none or cannot be read.
+func readWorkToolchain(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	work, err := modfile.ParseWork(path, data, nil)
+	if err != nil || work.Toolchain == nil {
+		return ""
+	}
+	return work.Toolchain.Name
+}
+
+// renderToolchainWarnings lists the modules whose toolchain directive is
+// below their go directive, whichever columns the table shows.
+func renderToolchainWarnings(w io.Writer, modules []moduleInfo, styled bool) {
+	for _, m := range modules {
+		if !toolchainBelowGo(m.Toolchain, m.GoVersion) {
+			continue
+		}
+		line := fmt.Sprintf("%s: toolchain %s is below go %s, run with --toolchain to set a newer one",
+			components.ShortPath(m.Name), m.Toolchain, m.GoVersion)
+		fmt.Fprintln(w, colorLines(line, components.ColorRed, styled))
+	}
+}
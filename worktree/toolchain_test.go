@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/titpetric/tools/worktree/components"
+	"github.com/titpetric/tools/worktree/config"
+)
+
+func TestParseToolchain(t *testing.T) {
+	valid := map[string]string{
+		"go1.27.2":   "go1.27.2",
+		"1.27.2":     "go1.27.2",
+		" go1.28rc1": "go1.28rc1",
+		"none":       "none",
+	}
+	for input, want := range valid {
+		got, err := parseToolchain(input)
+		if err != nil || got != want {
+			t.Fatalf("parseToolchain(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "go", "v1.27.2", "1.27.", "latest"} {
+		if got, err := parseToolchain(input); err == nil {
+			t.Fatalf("parseToolchain(%q) = %q, want an error", input, got)
+		}
+	}
+}
+
+func TestToolchainChange(t *testing.T) {
+	tests := map[[2]string]string{
+		{"go1.26.1", "go1.27.2"}: "toolchain go1.26.1 → go1.27.2",
+		{"", "go1.27.2"}:         "+ toolchain go1.27.2",
+		{"go1.26.1", ""}:         "- toolchain go1.26.1",
+	}
+	for change, want := range tests {
+		if got := toolchainChange(change[0], change[1]); got != want {
+			t.Fatalf("toolchainChange(%q, %q) = %q, want %q", change[0], change[1], got, want)
+		}
+	}
+}
+
+func TestToolchainBelowGo(t *testing.T) {
+	tests := []struct {
+		toolchain, goVersion string
+		want                 bool
+	}{
+		{"go1.25.3", "1.26", true},
+		{"go1.26rc1", "1.26.0", true},
+		{"go1.26.0", "1.26", false},
+		{"go1.27.2", "1.26.1", false},
+		{"default", "1.26", false},
+		{"", "1.26", false},
+		{"go1.25.3", "", false},
+	}
+	for _, tt := range tests {
+		if got := toolchainBelowGo(tt.toolchain, tt.goVersion); got != tt.want {
+			t.Errorf("toolchainBelowGo(%q, %q) = %v, want %v", tt.toolchain, tt.goVersion, got, tt.want)
+		}
+	}
+}
+
+func TestSetToolchain(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "go.mod")
+	writeTestFile(t, path, "module example.com/app\n\ngo 1.26\n\nrequire example.com/lib v1.0.0\n")
+
+	before, err := setToolchain(root, "go1.27.2")
+	if err != nil || before != "" {
+		t.Fatalf("setToolchain() = %q, %v, want no previous toolchain", before, err)
+	}
+	got := readTestFile(t, path)
+	if !strings.Contains(got, "toolchain go1.27.2\n") || !strings.Contains(got, "require example.com/lib v1.0.0") {
+		t.Fatalf("go.mod missing the toolchain or its requirements:\n%s", got)
+	}
+	if before, err = setToolchain(root, "go1.27.2"); err != nil || before != "go1.27.2" {
+		t.Fatalf("setToolchain() again = %q, %v, want it left alone", before, err)
+	}
+
+	// A toolchain below the go directive is refused and the file kept.
+	if _, err := setToolchain(root, "go1.25.3"); err == nil || !strings.Contains(err.Error(), "below go 1.26") {
+		t.Fatalf("setToolchain(go1.25.3) error = %v, want it refused", err)
+	}
+	if got := readTestFile(t, path); !strings.Contains(got, "toolchain go1.27.2\n") {
+		t.Fatalf("a refused toolchain rewrote go.mod:\n%s", got)
+	}
+
+	if before, err = setToolchain(root, toolchainNone); err != nil || before != "go1.27.2" {
+		t.Fatalf("setToolchain(none) = %q, %v, want go1.27.2 removed", before, err)
+	}
+	if got := readTestFile(t, path); strings.Contains(got, "toolchain") {
+		t.Fatalf("setToolchain(none) kept the directive:\n%s", got)
+	}
+}
+
+func TestUpdateGoWorkFilesToolchain(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.work"), "go 1.26\n\ntoolchain go1.26.1\n\nuse ./app\n")
+	chdir(t, root)
+
+	var output bytes.Buffer
+	if _, err := updateGoWorkFiles(&output, ".", &Options{Toolchain: "go1.27.2"}, config.Default().Scan, false); err != nil {
+		t.Fatalf("updateGoWorkFiles() error: %v", err)
+	}
+	if got, want := output.String(), "./go.work: toolchain go1.26.1 → go1.27.2\n"; got != want {
+		t.Fatalf("updateGoWorkFiles() = %q, want %q", got, want)
+	}
+	if got := readTestFile(t, filepath.Join(root, "go.work")); !strings.Contains(got, "toolchain go1.27.2\n") {
+		t.Fatalf("go.work not updated:\n%s", got)
+	}
+	// With --go as well, the toolchain the new go directive drops is the one
+	// replaced.
+	writeTestFile(t, filepath.Join(root, "go.work"), "go 1.26\n\ntoolchain go1.26.1\n\nuse ./app\n")
+	output.Reset()
+	updates, err := updateGoWorkFiles(&output, ".", &Options{GoVersion: "1.27", Toolchain: "go1.27.2"}, config.Default().Scan, false)
+	if err != nil {
+		t.Fatalf("updateGoWorkFiles() error: %v", err)
+	}
+	if got, want := output.String(), "./go.work: go 1.26 → 1.27\n./go.work: toolchain go1.26.1 → go1.27.2\n"; got != want {
+		t.Fatalf("updateGoWorkFiles() = %q, want %q", got, want)
+	}
+	if len(updates) != 1 || updates[0].toolchainFrom != "go1.26.1" || updates[0].toolchainTo != "go1.27.2" {
+		t.Fatalf("updateGoWorkFiles() updates = %#v, want one replacing go1.26.1", updates)
+	}
+}
+
+// TestUpdateDepsSetsToolchain checks a toolchain alone is set without
+// running the go tool, which could not resolve the module's import with
+// GOPROXY=off, and is recorded for the commit.
+func TestUpdateDepsSetsToolchain(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "go.mod")
+	writeTestFile(t, path, "module example.com/app\n\ngo 1.26\n\ntoolchain go1.26.1\n")
+	writeTestFile(t, filepath.Join(root, "main.go"), "package main\n\nimport _ \"example.com/lib\"\n\nfunc main() {}\n")
+	t.Setenv("GOPROXY", "off")
+	chdir(t, root)
+
+	var output bytes.Buffer
+	tags := latestTags{"example.com/lib": "v1.2.0"}
+	updates := updateDeps(&output, map[string]string{"example.com/app": "."}, tags, &Options{Toolchain: "go1.27.2"}, false)
+
+	want := "| Path | Module | Update status |\n" +
+		"| --- | --- | --- |\n" +
+		"| . | example.com/app | toolchain go1.26.1 → go1.27.2 |\n"
+	if got := output.String(); got != want {
+		t.Fatalf("updateDeps() markdown =\n%s\nwant:\n%s", got, want)
+	}
+	if len(updates) != 1 || commitMessage(updates) != "deps: toolchain go1.26.1 → go1.27.2" {
+		t.Fatalf("updateDeps() updates = %#v, want the toolchain change to commit", updates)
+	}
+
+	output.Reset()
+	updates = updateDeps(&output, map[string]string{"example.com/app": "."}, tags, &Options{Toolchain: "go1.27.2"}, false)
+	if got := output.String(); !strings.Contains(got, "Already up to date.") || len(updates) != 0 {
+		t.Fatalf("updateDeps() again =\n%s\nwant the module up to date", got)
+	}
+
+	// A toolchain the go directive is set above is dropped by --go, and the
+	// new one takes its place, reported as replacing the one dropped.
+	writeTestFile(t, path, "module example.com/app\n\ngo 1.26\n\ntoolchain go1.26.1\n")
+	s, update := &status{}, &moduleUpdate{}
+	setDirectives(".", &Options{GoVersion: "1.27", Toolchain: "go1.27.2"}, s, update)
+	if got := readTestFile(t, path); !strings.Contains(got, "go 1.27\n") || !strings.Contains(got, "toolchain go1.27.2\n") {
+		t.Fatalf("setDirectives() left:\n%s", got)
+	}
+	if got, want := s.String(), "go 1.26 → 1.27\ntoolchain go1.26.1 → go1.27.2"; got != want {
+		t.Fatalf("setDirectives() status = %q, want %q", got, want)
+	}
+	if update.toolchainFrom != "go1.26.1" || update.toolchainTo != "go1.27.2" {
+		t.Fatalf("setDirectives() toolchain = %q → %q, want go1.26.1 → go1.27.2", update.toolchainFrom, update.toolchainTo)
+	}
+
+	// A toolchain --go drops on its own is recorded for the commit.
+	writeTestFile(t, path, "module example.com/app\n\ngo 1.26\n\ntoolchain go1.26.1\n")
+	update = &moduleUpdate{modPath: "example.com/app"}
+	setDirectives(".", &Options{GoVersion: "1.27"}, &status{}, update)
+	if got := commitMessage([]moduleUpdate{*update}); !strings.Contains(got, "- toolchain go1.26.1") {
+		t.Fatalf("setDirectives() commit message = %q, want the dropped toolchain", got)
+	}
+}
+
+func TestRenderTablesToolchain(t *testing.T) {
+	modules := []moduleInfo{
+		{Name: "example.com/app", Path: "./app", GoVersion: "1.26", Toolchain: "go1.25.3", GitState: &components.Git{BranchName: "main"}},
+		{Name: "example.com/lib", Path: "./lib", GoVersion: "1.26", Toolchain: "go1.27.2", GitState: &components.Git{BranchName: "main"}},
+	}
+
+	var out strings.Builder
+	renderTables(&out, modules, &Options{All: true, Columns: []string{"module", "go", "toolchain"}}, false)
+	got := out.String()
+	for _, want := range []string{
+		"| Module | Go | Toolchain |",
+		"| ./app | 1.26 | go1.25.3 |",
+		"example.com/app: toolchain go1.25.3 is below go 1.26",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("renderTables() =\n%s\nwant it to hold %q", got, want)
+		}
+	}
+	if strings.Contains(got, "example.com/lib: toolchain") {
+		t.Fatalf("renderTables() warned about a toolchain above the go directive:\n%s", got)
+	}
+}
+
+func TestParseOptionsToolchain(t *testing.T) {
+	originalArgs := os.Args
+	originalFlags := flag.CommandLine
+	defer func() {
+		os.Args = originalArgs
+		flag.CommandLine = originalFlags
+	}()
+	os.Args = []string{"worktree", "--toolchain", "1.27.2", "--commit", "./..."}
+	flag.CommandLine = flag.NewFlagSet("worktree", flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseOptions()
+	if opts.Toolchain != "go1.27.2" || !opts.Commit || opts.FilterArg != "" {
+		t.Fatalf("ParseOptions() = %#v, want the toolchain go1.27.2 committed", opts)
+	}
+}
@@ -1,6 +1,10 @@
 package main
 
-import "github.com/titpetric/tools/worktree/components"
+import (
+	"golang.org/x/mod/modfile"
+
+	"github.com/titpetric/tools/worktree/components"
+)
 
 type projectDir struct {
 	Path     string
@@ -10,6 +14,7 @@ type projectDir struct {
 
 type gitStatus struct {
 	Unpushed  int
+	Behind    int
 	Modified  int
 	DiffLines []string // git diff --stat output lines
 }
@@ -19,7 +24,11 @@ type moduleInfo struct {
 	Path        string
 	Description string
 	Latest      string
+	TagPrefix   string
 	GoVersion   string
+	Toolchain   string
+	Retractions []modfile.VersionInterval
+	Activity    activity
 	GitState    *components.Git
 	Usage       components.Usage
 	Outdated    int
@@ -119,6 +119,70 @@ func diffRequires(before, after []requireInfo) []depChange {
 	return changes
 }
 
+// moduleUpdate records what an update rewrote in the go.mod of one module,
+// or in a go.work, the material a commit message is written from.
+type moduleUpdate struct {
+	dir     string
+	modPath string
+
+	// work marks the update of the go.work in dir rather than of a module,
+	// modPath then naming the file.
+	work bool
+
+	// goFrom and goTo hold the go directive change, both empty when the
+	// directive was left alone.
+	goFrom string
+	goTo   string
+
+	// toolchainFrom and toolchainTo hold the toolchain directive change,
+	// both empty when the directive was left alone. Either is empty when the
+	// directive was added or removed.
+	toolchainFrom string
+	toolchainTo   string
+
+	changes []depChange
+}
+
+// empty reports whether the update changed nothing worth committing.
+func (u moduleUpdate) empty() bool {
+	return u.goTo == "" && u.toolchainFrom == u.toolchainTo && len(u.changes) == 0
+}
+
+// setDirectives applies --go and --toolchain to the go.mod in dir, the go
+// directive first, so a toolchain it leaves stale is dropped before the new
+// one is set. The toolchain change is reported against the directive the
+// file had before either, so one the go directive drops is reported too.
+// Changes and failures are added to the status and recorded in the update.
+// It reports whether the file changed.
+func setDirectives(dir string, opts *Options, s *status, update *moduleUpdate) bool {
+	changed := false
+	toolchain := readToolchain(dir)
+	if opts.GoVersion != "" {
+		prev, err := setGoVersion(dir, opts.GoVersion)
+		switch {
+		case err != nil:
+			s.failed = true
+			s.add(components.ColorRed, "failed to set go version: %v", err)
+		case prev != opts.GoVersion:
+			s.add(components.ColorAmber, "%s", goVersionChange(prev, opts.GoVersion))
+			update.goFrom, update.goTo = prev, opts.GoVersion
+			changed = true
+		}
+	}
+	if opts.Toolchain != "" {
+		if _, err := setToolchain(dir, opts.Toolchain); err != nil {
+			s.failed = true
+			s.add(components.ColorRed, "failed to set toolchain: %v", err)
+		}
+	}
+	if next := readToolchain(dir); next != toolchain {
+		s.add(components.ColorAmber, "%s", toolchainChange(toolchain, next))
+		update.toolchainFrom, update.toolchainTo = toolchain, next
+		changed = true
+	}
+	return changed
+}
+
 // staleRequires returns the workspace requirements of reqs that don't reference
 // the latest tag of the module they require, paired with the tag to move to.
 func staleRequires(reqs []requireInfo, tags latestTags) []requireInfo {
@@ -141,8 +205,12 @@ func staleRequires(reqs []requireInfo, tags latestTags) []requireInfo {
 // When opts.GoVersion is set, the go directive of each go.mod is rewritten
 // before the dependencies are updated; a module already declaring that version
 // is reported as up to date and skipped, unless -u asked for a dependency
-// update as well.
-func updateDeps(w io.Writer, modPaths map[string]string, tags latestTags, opts *Options, styled bool) {
+// update as well. opts.Toolchain sets the toolchain directive the same way,
+// but a new toolchain alone does not run the go tool.
+//
+// The returned updates hold the go.mod changes of every module that updated
+// without a failure, so they can be committed afterwards.
+func updateDeps(w io.Writer, modPaths map[string]string, tags latestTags, opts *Options, styled bool) []moduleUpdate {
 	verbose := opts.Verbose
 
 	mods := make([]string, 0, len(modPaths))
@@ -158,28 +226,29 @@ func updateDeps(w io.Writer, modPaths map[string]string, tags latestTags, opts *
 		widths[1] = max(widths[1], ansi.StringWidth(components.ShortPath(modPath)))
 	}
 
-	table := newStreamTable(w, headers, widths, styled)
+	table := newStreamTable(w, headers, widths, terminalWidth(w, opts), styled)
 	defer table.close()
 
+	var updates []moduleUpdate
 	for _, modPath := range mods {
 		dir := modPaths[modPath]
 		table.start(relPath(dir), components.ShortPath(modPath))
 
 		s := &status{styled: styled}
+		update := moduleUpdate{dir: dir, modPath: modPath}
 		changed := false
-		if opts.GoVersion != "" {
-			prev, err := setGoVersion(dir, opts.GoVersion)
-			switch {
-			case err != nil:
-				s.failed = true
-				s.add(components.ColorRed, "failed to set go version: %v", err)
-			case prev != opts.GoVersion:
-				s.add(components.ColorAmber, "%s", goVersionChange(prev, opts.GoVersion))
-				changed = true
-			case !opts.Update:
-				// The go.mod already declares the version, so there is
-				// nothing to rewrite and no reason to run the go tool.
-				s.add(components.ColorGreen, "Already up to date.")
+		if opts.GoVersion != "" || opts.Toolchain != "" {
+			changed = setDirectives(dir, opts, s, &update)
+			// Without -u only a new go directive gives the go tool anything
+			// to do. A go.mod that already declares the version, or that
+			// only had its toolchain changed, is done.
+			if !opts.Update && update.goTo == "" {
+				if s.empty() {
+					s.add(components.ColorGreen, "Already up to date.")
+				}
+				if !s.failed && !update.empty() {
+					updates = append(updates, update)
+				}
 				table.finish(s.String())
 				continue
 			}
@@ -216,13 +285,18 @@ func updateDeps(w io.Writer, modPaths map[string]string, tags latestTags, opts *
 		s.run(dir, verbose, "go", "mod", "tidy")
 
 		after, _ := readRequiresVersioned(dir)
-		for _, change := range diffRequires(before, after) {
+		update.changes = diffRequires(before, after)
+		for _, change := range update.changes {
 			s.add(change.Color(), "%s", change)
 		}
 		if s.empty() {
 			s.add(components.ColorGreen, "Already up to date.")
 		}
+		if !s.failed && !update.empty() {
+			updates = append(updates, update)
+		}
 
 		table.finish(s.String())
 	}
+	return updates
 }
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// commandVuln reports the known vulnerabilities of the workspace requirements.
+const commandVuln = "vuln"
+
+// osvEntry is the part of an OSV record the report reads. The database is
+// the one govulncheck reads, mirrored to disk: index/modules.json lists the
+// records by module, and ID/<id>.json holds each record.
+type osvEntry struct {
+	ID       string        `json:"id"`
+	Summary  string        `json:"summary"`
+	Aliases  []string      `json:"aliases"`
+	Affected []osvAffected `json:"affected"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Ranges []osvRange `json:"ranges"`
+}
+
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+type osvEvent struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+
+	// LastAffected closes a range at the last version known to be
+	// affected, without naming a fix.
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// vulnDB is an OSV database directory on disk. Records are read as a module
+// asks for them, so a report over a few modules reads a few files.
+type vulnDB struct {
+	dir      string
+	byModule map[string][]string
+	entries  map[string]*osvEntry
+}
+
+// vulnDBDir returns the database directory: the flag value, or else the
+// GOVULNDB environment variable govulncheck reads, as a path or a file URL.
+func vulnDBDir(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if env := os.Getenv("GOVULNDB"); env != "" {
+		if dir, ok := strings.CutPrefix(env, "file://"); ok {
+			return dir, nil
+		}
+		if !strings.Contains(env, "://") {
+			return env, nil
+		}
+		return "", fmt.Errorf("GOVULNDB=%s is not a local directory; vuln works offline, give --db a synced copy", env)
+	}
+	return "", errors.New("vuln needs the database directory, as --db <dir> or GOVULNDB=file:///path")
+}
+
+// openVulnDB opens the database at dir, indexing its records by module from
+// index/modules.json, or from the records themselves when there is no index.
+func openVulnDB(dir string) (*vulnDB, error) {
+	db := &vulnDB{dir: dir, byModule: make(map[string][]string), entries: make(map[string]*osvEntry)}
+
+	data, err := os.ReadFile(filepath.Join(dir, "index", "modules.json"))
+	if err == nil {
+		var index []struct {
+			Path  string `json:"path"`
+			Vulns []struct {
+				ID string `json:"id"`
+			} `json:"vulns"`
+		}
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(dir, "index", "modules.json"), err)
+		}
+		for _, module := range index {
+			for _, vuln := range module.Vulns {
+				db.byModule[module.Path] = append(db.byModule[module.Path], vuln.ID)
+			}
+		}
+		return db, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "ID", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%s holds no vulnerability database: no index/modules.json or ID/*.json", dir)
+	}
+	for _, path := range paths {
+		entry, err := readOSVEntry(path)
+		if err != nil {
+			return nil, err
+		}
+		db.entries[entry.ID] = entry
+		for _, affected := range entry.Affected {
+			db.byModule[affected.Package.Name] = append(db.byModule[affected.Package.Name], entry.ID)
+		}
+	}
+	return db, nil
+}
+
+func readOSVEntry(path string) (*osvEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entry := &osvEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entry, nil
+}
+
+// lookup returns the records naming a module.
+func (db *vulnDB) lookup(module string) ([]*osvEntry, error) {
+	var entries []*osvEntry
+	for _, id := range db.byModule[module] {
+		entry, ok := db.entries[id]
+		if !ok {
+			var err error
+			entry, err = readOSVEntry(filepath.Join(db.dir, "ID", id+".json"))
+			if err != nil {
+				return nil, err
+			}
+			db.entries[id] = entry
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// osvVersion turns an OSV version, which has no "v", into the form semver
+// compares. "0" is the introduced event of a vulnerability present from the
+// first version.
+func osvVersion(version string) string {
+	if version == "0" {
+		return ""
+	}
+	return "v" + version
+}
+
+// compareOSV orders two OSV versions, "0" below every other.
+func compareOSV(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "0":
+		return -1
+	case b == "0":
+		return 1
+	}
+	return semver.Compare(osvVersion(a), osvVersion(b))
+}
+
+// affects reports whether the entry affects a module at version, and the
+// version fixing it, empty when there is none yet.
+func (e *osvEntry) affects(module, version string) (bool, string) {
+	v := strings.TrimPrefix(version, "v")
+	for _, affected := range e.Affected {
+		if affected.Package.Name != module {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			if r.Type != "SEMVER" {
+				continue
+			}
+			if hit, fixed := rangeAffects(r.Events, v); hit {
+				return true, fixed
+			}
+		}
+	}
+	return false, ""
+}
+
+// rangeAffects evaluates the events of a SEMVER range against a version
+// without its "v": each introduced event at or below the version turns it
+// affected, and each fixed event at or below it and last_affected event
+// below it turn it back. An affected version is fixed by the first fixed
+// event above it; a range closed by last_affected names no fix.
+func rangeAffects(events []osvEvent, version string) (bool, string) {
+	sorted := append([]osvEvent(nil), events...)
+	at := func(e osvEvent) string {
+		switch {
+		case e.Introduced != "":
+			return e.Introduced
+		case e.Fixed != "":
+			return e.Fixed
+		}
+		return e.LastAffected
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return compareOSV(at(sorted[i]), at(sorted[j])) < 0 })
+
+	affected := false
+	for _, e := range sorted {
+		if c := compareOSV(at(e), version); c > 0 || c == 0 && e.LastAffected != "" {
+			if affected && e.Fixed != "" {
+				return true, "v" + e.Fixed
+			}
+			continue
+		}
+		affected = e.Introduced != ""
+	}
+	return affected, ""
+}
+
+// vulnFinding is a requirement of a workspace module with known
+// vulnerabilities. fixed is the lowest version fixing them all, empty when
+// one of them has no fix. listed is set when go.mod names the requirement;
+// --fix moves only those, the go tool selects the rest.
+type vulnFinding struct {
+	dep     string
+	version string
+	ids     []string
+	fixed   string
+	listed  bool
+}
+
+// readGoSum returns the modules go.sum holds the sources of, each at the
+// highest version listed. The go.mod hashes of modules only consulted for
+// their requirements are left out.
+func readGoSum(dir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, "go.sum"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	versions := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		if current, ok := versions[fields[0]]; !ok || semver.Compare(fields[1], current) > 0 {
+			versions[fields[0]] = fields[1]
+		}
+	}
+	return versions, scanner.Err()
+}
+
+// moduleRequirements returns the requirements of the module in dir: the
+// versions go.mod selects, and for a module only go.sum names, the highest
+// version it holds. listed holds the modules go.mod names.
+func moduleRequirements(dir string) (versions map[string]string, listed map[string]bool, err error) {
+	reqs, err := readRequiresVersioned(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	versions, err = readGoSum(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if versions == nil {
+		versions = make(map[string]string)
+	}
+	listed = make(map[string]bool)
+	for _, r := range reqs {
+		versions[r.path] = r.version
+		listed[r.path] = true
+	}
+	return versions, listed, nil
+}
+
+// collectVulns matches the requirements of every workspace module against
+// the database, returning the findings of each module that has any, sorted
+// by dependency.
+func collectVulns(db *vulnDB, modPaths map[string]string) (map[string][]vulnFinding, error) {
+	findings := make(map[string][]vulnFinding)
+	for module, dir := range modPaths {
+		reqs, listed, err := moduleRequirements(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read requires for %s: %w", module, err)
+		}
+		for dep, version := range reqs {
+			entries, err := db.lookup(dep)
+			if err != nil {
+				return nil, err
+			}
+			finding := vulnFinding{dep: dep, version: version, listed: listed[dep]}
+			fixable := true
+			for _, entry := range entries {
+				hit, fixed := entry.affects(dep, version)
+				if !hit {
+					continue
+				}
+				finding.ids = append(finding.ids, entry.ID)
+				if fixed == "" {
+					fixable = false
+				} else if semver.Compare(fixed, finding.fixed) > 0 {
+					finding.fixed = fixed
+				}
+			}
+			if len(finding.ids) == 0 {
+				continue
+			}
+			if !fixable {
+				finding.fixed = ""
+			}
+			sort.Strings(finding.ids)
+			findings[module] = append(findings[module], finding)
+		}
+		sort.Slice(findings[module], func(i, j int) bool { return findings[module][i].dep < findings[module][j].dep })
+	}
+	return findings, nil
+}
+
+// renderVulns lists the findings of each workspace module, one line per
+// affected dependency.
+func renderVulns(w io.Writer, findings map[string][]vulnFinding, modPaths map[string]string, styled bool) {
+	if len(findings) == 0 {
+		fmt.Fprintf(w, "No known vulnerabilities in the requirements of %d modules.\n", len(modPaths))
+		return
+	}
+	modules := make([]string, 0, len(findings))
+	for module := range findings {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	fixable := 0
+	var rows [][]string
+	for _, module := range modules {
+		var deps, ids, fixed []string
+		for _, f := range findings[module] {
+			deps = append(deps, f.dep+" "+colorLines(f.version, components.ColorRed, styled))
+			ids = append(ids, strings.Join(f.ids, ", "))
+			if f.fixed == "" {
+				fixed = append(fixed, colorLines("no fix", components.ColorSeparator, styled))
+				continue
+			}
+			fixed = append(fixed, colorLines(f.fixed, components.ColorGreen, styled))
+			if f.listed {
+				fixable++
+			}
+		}
+		rows = append(rows, []string{
+			relPath(modPaths[module]),
+			components.ShortPath(module),
+			strings.Join(deps, "\n"),
+			strings.Join(ids, "\n"),
+			strings.Join(fixed, "\n"),
+		})
+	}
+	writeSimpleTable(w, []string{"Path", "Module", "Dependency", "Vulnerabilities", "Fixed"}, rows, styled)
+
+	if fixable > 0 {
+		borderColor, yellow, reset := "", "", ""
+		if styled {
+			borderColor, yellow, reset = components.ColorBorder, components.ColorYellow, components.ColorReset
+		}
+		fmt.Fprintf(w, "%srun with %s--fix%s %sto require the fixed version of %d dependencies%s\n",
+			borderColor, yellow, reset, borderColor, fixable, reset)
+	}
+}
+
+// fixTargets returns what fixing the findings takes: the version each
+// dependency moves to, in the form updateDeps moves workspace modules to
+// their latest tag with, and the directories of the modules to update.
+//
+// updateDeps moves every listed requirement of a module it updates to the
+// target, so a target is never below a version one of those modules already
+// requires; that module would otherwise be downgraded.
+func fixTargets(findings map[string][]vulnFinding, modPaths map[string]string) (latestTags, map[string]string) {
+	targets := make(latestTags)
+	dirs := make(map[string]string)
+	for module, list := range findings {
+		for _, f := range list {
+			if f.fixed == "" || !f.listed {
+				continue
+			}
+			if semver.Compare(f.fixed, targets[f.dep]) > 0 {
+				targets[f.dep] = f.fixed
+			}
+			dirs[module] = modPaths[module]
+		}
+	}
+	for _, dir := range dirs {
+		reqs, err := readRequiresVersioned(dir)
+		if err != nil {
+			continue
+		}
+		for _, r := range reqs {
+			if target, ok := targets[r.path]; ok && semver.Compare(r.version, target) > 0 {
+				targets[r.path] = r.version
+			}
+		}
+	}
+	return targets, dirs
+}
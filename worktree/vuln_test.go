@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// vulnDatabase writes an OSV database with two records against
+// golang.org/x/net, one fixed in 0.33.0 and one fixed in 0.36.0, and one
+// against golang.org/x/text with no fix. index writes index/modules.json.
+func vulnDatabase(t *testing.T, index bool) string {
+	t.Helper()
+	dir := t.TempDir()
+	records := map[string]string{
+		"GO-2024-0001": `{"id":"GO-2024-0001","affected":[{"package":{"name":"golang.org/x/net","ecosystem":"Go"},` +
+			`"ranges":[{"type":"SEMVER","events":[{"introduced":"0"},{"fixed":"0.33.0"}]}]}]}`,
+		"GO-2025-0002": `{"id":"GO-2025-0002","affected":[{"package":{"name":"golang.org/x/net","ecosystem":"Go"},` +
+			`"ranges":[{"type":"SEMVER","events":[{"introduced":"0.20.0"},{"fixed":"0.36.0"}]}]}]}`,
+		"GO-2025-0003": `{"id":"GO-2025-0003","affected":[{"package":{"name":"golang.org/x/text","ecosystem":"Go"},` +
+			`"ranges":[{"type":"SEMVER","events":[{"introduced":"0.10.0"}]}]}]}`,
+	}
+	for id, record := range records {
+		writeTestFile(t, filepath.Join(dir, "ID", id+".json"), record)
+	}
+	if index {
+		writeTestFile(t, filepath.Join(dir, "index", "modules.json"),
+			`[{"path":"golang.org/x/net","vulns":[{"id":"GO-2024-0001"},{"id":"GO-2025-0002"}]},`+
+				`{"path":"golang.org/x/text","vulns":[{"id":"GO-2025-0003"}]}]`)
+	}
+	return dir
+}
+
+// vulnWorkspace writes a module requiring golang.org/x/net below both fixes,
+// with golang.org/x/text only in go.sum, and a module already on the fixed
+// golang.org/x/net.
+func vulnWorkspace(t *testing.T) map[string]string {
+	t.Helper()
+	root := t.TempDir()
+	api := filepath.Join(root, "api")
+	writeTestFile(t, filepath.Join(api, "go.mod"),
+		"module example.com/api\n\ngo 1.25\n\nrequire golang.org/x/net v0.30.0\n")
+	writeTestFile(t, filepath.Join(api, "go.sum"),
+		"golang.org/x/net v0.30.0 h1:abc=\n"+
+			"golang.org/x/net v0.30.0/go.mod h1:abc=\n"+
+			"golang.org/x/text v0.19.0 h1:abc=\n"+
+			"golang.org/x/text v0.19.0/go.mod h1:abc=\n"+
+			"golang.org/x/text v0.21.0/go.mod h1:abc=\n")
+	core := filepath.Join(root, "core")
+	writeTestFile(t, filepath.Join(core, "go.mod"),
+		"module example.com/core\n\ngo 1.25\n\nrequire golang.org/x/net v0.38.0\n")
+	return map[string]string{"example.com/api": api, "example.com/core": core}
+}
+
+func TestRangeAffects(t *testing.T) {
+	events := []osvEvent{{Fixed: "1.2.0"}, {Introduced: "0"}, {Introduced: "1.5.0"}, {Fixed: "1.5.3"}}
+	tests := []struct {
+		version  string
+		affected bool
+		fixed    string
+	}{
+		{"1.0.0", true, "v1.2.0"},
+		{"1.2.0", false, ""},
+		{"1.4.9", false, ""},
+		{"1.5.0", true, "v1.5.3"},
+		{"1.5.3", false, ""},
+		{"2.0.0", false, ""},
+	}
+	for _, tt := range tests {
+		affected, fixed := rangeAffects(events, tt.version)
+		if affected != tt.affected || fixed != tt.fixed {
+			t.Errorf("rangeAffects(%s) = %v, %q, want %v, %q", tt.version, affected, fixed, tt.affected, tt.fixed)
+		}
+	}
+
+	affected, fixed := rangeAffects([]osvEvent{{Introduced: "0.10.0"}}, "0.19.0")
+	if !affected || fixed != "" {
+		t.Fatalf("rangeAffects() with no fix = %v, %q, want affected with no fix", affected, fixed)
+	}
+
+	lastAffected := []osvEvent{{Introduced: "0"}, {LastAffected: "1.2.0"}}
+	for _, tt := range []struct {
+		version  string
+		affected bool
+	}{
+		{"1.1.0", true},
+		{"1.2.0", true},
+		{"1.2.1", false},
+		{"2.0.0", false},
+	} {
+		affected, fixed := rangeAffects(lastAffected, tt.version)
+		if affected != tt.affected || fixed != "" {
+			t.Errorf("rangeAffects(%s) up to last_affected = %v, %q, want %v with no fix", tt.version, affected, fixed, tt.affected)
+		}
+	}
+}
+
+func TestCollectVulns(t *testing.T) {
+	for _, index := range []bool{true, false} {
+		db, err := openVulnDB(vulnDatabase(t, index))
+		if err != nil {
+			t.Fatalf("openVulnDB(index=%v) error: %v", index, err)
+		}
+		modPaths := vulnWorkspace(t)
+		findings, err := collectVulns(db, modPaths)
+		if err != nil {
+			t.Fatalf("collectVulns() error: %v", err)
+		}
+		want := map[string][]vulnFinding{
+			"example.com/api": {
+				{dep: "golang.org/x/net", version: "v0.30.0", ids: []string{"GO-2024-0001", "GO-2025-0002"}, fixed: "v0.36.0", listed: true},
+				{dep: "golang.org/x/text", version: "v0.19.0", ids: []string{"GO-2025-0003"}},
+			},
+		}
+		if !reflect.DeepEqual(findings, want) {
+			t.Fatalf("collectVulns(index=%v) = %#v, want %#v", index, findings, want)
+		}
+	}
+}
+
+func TestOpenVulnDBEmpty(t *testing.T) {
+	if _, err := openVulnDB(t.TempDir()); err == nil {
+		t.Fatal("openVulnDB() of an empty directory succeeded")
+	}
+}
+
+func TestVulnDBDir(t *testing.T) {
+	t.Setenv("GOVULNDB", "file:///srv/vulndb")
+	if dir, err := vulnDBDir(""); err != nil || dir != "/srv/vulndb" {
+		t.Fatalf("vulnDBDir() = %q, %v, want /srv/vulndb", dir, err)
+	}
+	if dir, _ := vulnDBDir("db"); dir != "db" {
+		t.Fatalf("vulnDBDir(db) = %q, want the flag to win", dir)
+	}
+	t.Setenv("GOVULNDB", "https://vuln.go.dev")
+	if _, err := vulnDBDir(""); err == nil {
+		t.Fatal("vulnDBDir() accepted a remote database")
+	}
+	os.Unsetenv("GOVULNDB")
+	if _, err := vulnDBDir(""); err == nil {
+		t.Fatal("vulnDBDir() without a database succeeded")
+	}
+}
+
+func TestRenderVulns(t *testing.T) {
+	db, err := openVulnDB(vulnDatabase(t, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modPaths := vulnWorkspace(t)
+	findings, err := collectVulns(db, modPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	renderVulns(&output, findings, modPaths, false)
+	got := output.String()
+	row := "| example.com/api | golang.org/x/net v0.30.0<br>golang.org/x/text v0.19.0 | GO-2024-0001, GO-2025-0002<br>GO-2025-0003 | v0.36.0<br>no fix |"
+	if !strings.Contains(got, row) {
+		t.Fatalf("renderVulns() =\n%s\nwant a row ending in:\n%s", got, row)
+	}
+	if !strings.Contains(got, "--fix") || !strings.Contains(got, "of 1 dependencies") {
+		t.Fatalf("renderVulns() = %q, want the --fix hint for one dependency", got)
+	}
+
+	output.Reset()
+	renderVulns(&output, nil, modPaths, false)
+	if got := output.String(); got != "No known vulnerabilities in the requirements of 2 modules.\n" {
+		t.Fatalf("renderVulns() with no findings = %q", got)
+	}
+}
+
+func TestFixTargetsNeverDowngrade(t *testing.T) {
+	modPaths := vulnWorkspace(t)
+	findings := map[string][]vulnFinding{
+		"example.com/api": {
+			{dep: "golang.org/x/net", version: "v0.30.0", fixed: "v0.36.0", listed: true},
+			{dep: "golang.org/x/text", version: "v0.19.0", fixed: "v0.22.0"},
+		},
+		"example.com/core": {
+			{dep: "golang.org/x/net", version: "v0.38.0", fixed: "v0.33.0", listed: true},
+		},
+	}
+	targets, dirs := fixTargets(findings, modPaths)
+	// core requires v0.38.0 already; moving it to v0.36.0 would downgrade it.
+	if !reflect.DeepEqual(targets, latestTags{"golang.org/x/net": "v0.38.0"}) {
+		t.Fatalf("fixTargets() targets = %v", targets)
+	}
+	if !reflect.DeepEqual(dirs, modPaths) {
+		t.Fatalf("fixTargets() dirs = %v, want both modules", dirs)
+	}
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// A --where expression selects modules by their state, for example
+//
+//	outdated > 0 && branch != "main"
+//	ahead > 3 || has(untracked)
+//	go < 1.23
+//
+// Comparisons take a field on one side and a field or a literal on the other.
+// A field standing alone, or wrapped in has(), is true when it is set: a
+// count above zero, a string that is not empty. A name that is not a field
+// can be a saved query from the configuration, which stands for its
+// expression.
+
+// whereKind is the type of a field, which decides how it compares.
+type whereKind int
+
+const (
+	kindInt whereKind = iota
+	kindString
+	kindVersion
+	kindBool
+)
+
+// whereField is a field of a module a --where expression can read.
+type whereField struct {
+	kind whereKind
+	get  func(m moduleInfo) string
+}
+
+// whereFields holds the fields by name. Every field reads as text and is
+// parsed by its kind when it is compared, so a field and a literal compare
+// the same way.
+var whereFields = map[string]whereField{
+	"name":        {kindString, func(m moduleInfo) string { return m.Name }},
+	"short":       {kindString, func(m moduleInfo) string { return components.ShortName(m.Name) }},
+	"path":        {kindString, func(m moduleInfo) string { return m.Path }},
+	"description": {kindString, func(m moduleInfo) string { return m.Description }},
+	"branch":      {kindString, func(m moduleInfo) string { return whereGit(m).BranchName }},
+	"latest":      {kindVersion, func(m moduleInfo) string { return m.Latest }},
+	"go":          {kindVersion, func(m moduleInfo) string { return m.GoVersion }},
+	"ahead":       {kindInt, func(m moduleInfo) string { return strconv.Itoa(whereGit(m).Ahead) }},
+	"unpushed":    {kindInt, func(m moduleInfo) string { return strconv.Itoa(whereGit(m).Unpushed) }},
+	"behind":      {kindInt, func(m moduleInfo) string { return strconv.Itoa(whereGit(m).Behind) }},
+	"changes":     {kindInt, func(m moduleInfo) string { return strconv.Itoa(len(whereGit(m).DiffLines)) }},
+	"untracked":   {kindInt, func(m moduleInfo) string { return strconv.Itoa(len(whereGit(m).UntrackedFiles)) }},
+	"issues":      {kindInt, func(m moduleInfo) string { return strconv.Itoa(len(whereGit(m).Issues)) }},
+	"outdated":    {kindInt, func(m moduleInfo) string { return strconv.Itoa(m.Outdated) }},
+	"uses":        {kindInt, func(m moduleInfo) string { return strconv.Itoa(len(m.Uses)) }},
+	"usedby":      {kindInt, func(m moduleInfo) string { return strconv.Itoa(len(m.UsedBy)) }},
+	"diverged":    {kindBool, func(m moduleInfo) string { return strconv.FormatBool(whereGit(m).Diverged()) }},
+}
+
+// whereGit returns the git state of a module, empty when it has none.
+func whereGit(m moduleInfo) components.Git {
+	if m.GitState == nil {
+		return components.Git{}
+	}
+	return *m.GitState
+}
+
+// whereFieldNames returns the field names in order, for error messages.
+func whereFieldNames() string {
+	names := make([]string, 0, len(whereFields))
+	for name := range whereFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// wherePredicate reports whether a module matches an expression.
+type wherePredicate func(m moduleInfo) bool
+
+// parseWhere compiles an expression, resolving the saved queries it names.
+func parseWhere(expr string, queries map[string]string) (wherePredicate, error) {
+	p := &whereParser{queries: queries}
+	pred, err := p.parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("--where %q: %w", expr, err)
+	}
+	return pred, nil
+}
+
+// filterWhere returns the modules that match pred, in their order.
+func filterWhere(modules []moduleInfo, pred wherePredicate) []moduleInfo {
+	var matched []moduleInfo
+	for _, m := range modules {
+		if pred(m) {
+			matched = append(matched, m)
+		}
+	}
+	return matched
+}
+
+// whereToken is one token of an expression. quoted marks a string literal,
+// so "ahead" can be compared as text rather than read as a field.
+type whereToken struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+// whereOperators are the operator tokens, longest first so "<=" is not read
+// as "<".
+var whereOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")"}
+
+// lexWhere splits an expression into tokens.
+func lexWhere(expr string) ([]whereToken, error) {
+	var tokens []whereToken
+	for i := 0; i < len(expr); {
+		r := rune(expr[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := strings.IndexRune(expr[i+1:], r)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at column %d", i+1)
+			}
+			tokens = append(tokens, whereToken{text: expr[i+1 : i+1+end], quoted: true, pos: i})
+			i += end + 2
+		case isWhereWord(r):
+			start := i
+			for i < len(expr) && isWhereWord(rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, whereToken{text: expr[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range whereOperators {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at column %d", expr[i], i+1)
+			}
+			tokens = append(tokens, whereToken{text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+// isWhereWord reports whether r belongs to a field name or a bare literal
+// such as 1.23, v1.2.0 or feature/login.
+func isWhereWord(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-+/", r))
+}
+
+// whereParser is a recursive descent parser over the tokens of one
+// expression:
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | primary
+//	primary = "(" or ")" | "has" "(" field ")" | operand [ compare operand ]
+type whereParser struct {
+	queries map[string]string
+	tokens  []whereToken
+	next    int
+	// resolving holds the saved queries being expanded, so a query that
+	// names itself is reported rather than expanded forever.
+	resolving []string
+}
+
+func (p *whereParser) parse(expr string) (wherePredicate, error) {
+	tokens, err := lexWhere(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	outer := p.tokens
+	outerNext := p.next
+	p.tokens, p.next = tokens, 0
+	defer func() { p.tokens, p.next = outer, outerNext }()
+
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q at column %d", tok.text, tok.pos+1)
+	}
+	return pred, nil
+}
+
+func (p *whereParser) peek() (whereToken, bool) {
+	if p.next >= len(p.tokens) {
+		return whereToken{}, false
+	}
+	return p.tokens[p.next], true
+}
+
+// accept consumes the next token when it is the operator op.
+func (p *whereParser) accept(op string) bool {
+	if tok, ok := p.peek(); ok && !tok.quoted && tok.text == op {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *whereParser) expect(op string) error {
+	if p.accept(op) {
+		return nil
+	}
+	if tok, ok := p.peek(); ok {
+		return fmt.Errorf("want %q at column %d, got %q", op, tok.pos+1, tok.text)
+	}
+	return fmt.Errorf("want %q at the end", op)
+}
+
+func (p *whereParser) or() (wherePredicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(m moduleInfo) bool { return l(m) || right(m) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) and() (wherePredicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(m moduleInfo) bool { return l(m) && right(m) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) unary() (wherePredicate, error) {
+	if p.accept("!") {
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(m moduleInfo) bool { return !inner(m) }, nil
+	}
+	return p.primary()
+}
+
+func (p *whereParser) primary() (wherePredicate, error) {
+	if p.accept("(") {
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	if !tok.quoted && tok.text == "has" && p.next+1 < len(p.tokens) && p.tokens[p.next+1].text == "(" {
+		p.next += 2
+		name, ok := p.peek()
+		field, known := whereFields[name.text]
+		if !ok || name.quoted || !known {
+			return nil, fmt.Errorf("has() takes a field, one of %s", whereFieldNames())
+		}
+		p.next++
+		return func(m moduleInfo) bool { return whereTruthy(field, m) }, p.expect(")")
+	}
+	p.next++
+
+	if query, ok := p.queries[tok.text]; ok && !tok.quoted && whereFields[tok.text].get == nil {
+		if slices.Contains(p.resolving, tok.text) {
+			return nil, fmt.Errorf("query %q refers to itself", tok.text)
+		}
+		p.resolving = append(p.resolving, tok.text)
+		defer func() { p.resolving = p.resolving[:len(p.resolving)-1] }()
+		pred, err := p.parse(query)
+		if err != nil {
+			return nil, fmt.Errorf("query %q: %w", tok.text, err)
+		}
+		return pred, nil
+	}
+
+	left := p.operand(tok)
+	next, ok := p.peek()
+	if !ok || next.quoted || !slices.Contains([]string{"==", "!=", "<", "<=", ">", ">="}, next.text) {
+		if left.field == nil {
+			return nil, fmt.Errorf("unknown field %q at column %d, want one of %s", tok.text, tok.pos+1, whereFieldNames())
+		}
+		field := *left.field
+		return func(m moduleInfo) bool { return whereTruthy(field, m) }, nil
+	}
+	if _, version := parseWhereVersion(tok.text); left.field == nil && !tok.quoted && !version && unicode.IsLetter(rune(tok.text[0])) {
+		return nil, fmt.Errorf("unknown field %q at column %d, want one of %s", tok.text, tok.pos+1, whereFieldNames())
+	}
+	p.next++
+	rightTok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("%q wants a value after it", next.text)
+	}
+	p.next++
+	return whereCompare(left, next.text, p.operand(rightTok))
+}
+
+// whereOperand is one side of a comparison: a field, or a literal when field
+// is nil.
+type whereOperand struct {
+	field *whereField
+	text  string
+}
+
+// operand reads a token as a field, unless it is quoted or names none.
+func (p *whereParser) operand(tok whereToken) whereOperand {
+	if field, ok := whereFields[tok.text]; ok && !tok.quoted {
+		return whereOperand{field: &field}
+	}
+	return whereOperand{text: tok.text}
+}
+
+func (o whereOperand) value(m moduleInfo) string {
+	if o.field != nil {
+		return o.field.get(m)
+	}
+	return o.text
+}
+
+// whereTruthy reports whether a field is set on a module.
+func whereTruthy(field whereField, m moduleInfo) bool {
+	value := field.get(m)
+	switch field.kind {
+	case kindInt:
+		return value != "0"
+	case kindBool:
+		return value == "true"
+	}
+	return value != ""
+}
+
+// whereCompare builds a comparison. The kind of the field decides how the two
+// sides compare, and a literal is checked against it up front, so a typo
+// fails before any module is read.
+func whereCompare(left whereOperand, op string, right whereOperand) (wherePredicate, error) {
+	kind := kindString
+	switch {
+	case left.field != nil:
+		kind = left.field.kind
+	case right.field != nil:
+		kind = right.field.kind
+	default:
+		return nil, fmt.Errorf("%s %s %s compares no field", left.text, op, right.text)
+	}
+	for _, side := range []whereOperand{left, right} {
+		if side.field != nil {
+			if side.field.kind != kind {
+				return nil, fmt.Errorf("%q compares fields of different kinds", op)
+			}
+			continue
+		}
+		if err := checkWhereLiteral(kind, side.text); err != nil {
+			return nil, err
+		}
+	}
+	if kind == kindBool && op != "==" && op != "!=" {
+		return nil, fmt.Errorf("%q does not order true and false", op)
+	}
+
+	return func(m moduleInfo) bool {
+		order, ok := compareWhere(kind, left.value(m), right.value(m))
+		if !ok {
+			return false
+		}
+		switch op {
+		case "==":
+			return order == 0
+		case "!=":
+			return order != 0
+		case "<":
+			return order < 0
+		case "<=":
+			return order <= 0
+		case ">":
+			return order > 0
+		}
+		return order >= 0
+	}, nil
+}
+
+// checkWhereLiteral reports a literal that cannot be read as kind.
+func checkWhereLiteral(kind whereKind, text string) error {
+	switch kind {
+	case kindInt:
+		if _, err := strconv.Atoi(text); err != nil {
+			return fmt.Errorf("%q is not a number", text)
+		}
+	case kindVersion:
+		if _, ok := parseWhereVersion(text); !ok {
+			return fmt.Errorf("%q is not a version", text)
+		}
+	case kindBool:
+		if _, err := strconv.ParseBool(text); err != nil {
+			return fmt.Errorf("%q is not true or false", text)
+		}
+	}
+	return nil
+}
+
+// compareWhere orders two values of a kind. It reports false when either
+// cannot be read, such as the latest tag of a module never released, which
+// then matches no comparison.
+func compareWhere(kind whereKind, a, b string) (int, bool) {
+	switch kind {
+	case kindInt:
+		x, errA := strconv.Atoi(a)
+		y, errB := strconv.Atoi(b)
+		if errA != nil || errB != nil {
+			return 0, false
+		}
+		return x - y, true
+	case kindVersion:
+		x, okA := parseWhereVersion(a)
+		y, okB := parseWhereVersion(b)
+		if !okA || !okB {
+			return 0, false
+		}
+		return Compare(x, y), true
+	case kindBool:
+		x, errA := strconv.ParseBool(a)
+		y, errB := strconv.ParseBool(b)
+		if errA != nil || errB != nil || x == y {
+			return 0, errA == nil && errB == nil
+		}
+		return 1, true
+	}
+	return strings.Compare(a, b), true
+}
+
+// parseWhereVersion reads a tag such as v1.2.3, or a go directive such as
+// 1.23, where the patch number is optional.
+func parseWhereVersion(text string) (Version, bool) {
+	if v, ok := ParseVersion(text); ok {
+		return v, true
+	}
+	return ParseGoDirective(strings.TrimPrefix(text, "v"))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/titpetric/tools/worktree/components"
+)
+
+// whereModules is a small workspace to run expressions against.
+func whereModules() []moduleInfo {
+	return []moduleInfo{
+		{
+			Name: "example.com/api", Path: "./api", Latest: "v1.4.0", GoVersion: "1.22", Outdated: 2,
+			GitState: &components.Git{BranchName: "feature", Ahead: 5, UntrackedFiles: []string{"notes.txt"}},
+		},
+		{
+			Name: "example.com/core", Path: "./core", Latest: "v2.0.0", GoVersion: "1.25",
+			GitState: &components.Git{BranchName: "main", Ahead: 1},
+		},
+		{
+			Name: "example.com/tools", Path: "./tools", GoVersion: "1.23", Outdated: 1,
+			GitState: &components.Git{BranchName: "main"},
+		},
+	}
+}
+
+func TestWhere(t *testing.T) {
+	queries := map[string]string{"release": "ahead > 0", "loop": "loop"}
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`outdated > 0 && branch != "main"`, []string{"api"}},
+		{`ahead > 3`, []string{"api"}},
+		{`go < 1.23`, []string{"api"}},
+		{`go >= 1.23.0`, []string{"core", "tools"}},
+		{`has(untracked)`, []string{"api"}},
+		{`!has(untracked) && outdated`, []string{"tools"}},
+		{`latest < v2.0.0`, []string{"api"}},
+		{`latest`, []string{"api", "core"}},
+		{`(branch == main || ahead > 3) && outdated == 0`, []string{"core"}},
+		{`release && branch == 'main'`, []string{"core"}},
+		{`short == "tools"`, []string{"tools"}},
+		{`diverged == false && usedby == 0`, []string{"api", "core", "tools"}},
+	}
+	for _, test := range tests {
+		pred, err := parseWhere(test.expr, queries)
+		if err != nil {
+			t.Fatalf("parseWhere(%q) error: %v", test.expr, err)
+		}
+		var got []string
+		for _, m := range filterWhere(whereModules(), pred) {
+			got = append(got, components.ShortName(m.Name))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("--where %s = %v, want %v", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestWhereRejects(t *testing.T) {
+	queries := map[string]string{"loop": "loop && ahead > 0"}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{``, "empty expression"},
+		{`ahead >`, "wants a value"},
+		{`ahead > many`, `"many" is not a number`},
+		{`go < newest`, `"newest" is not a version`},
+		{`stars > 3`, `unknown field "stars"`},
+		{`has(stars)`, "has() takes a field"},
+		{`(ahead > 0`, `want ")"`},
+		{`ahead > 0 ahead`, `unexpected "ahead"`},
+		{`branch = "main"`, `unexpected '='`},
+		{`branch == "main`, "unterminated string"},
+		{`diverged < true`, "does not order"},
+		{`1 < 2`, "compares no field"},
+		{`loop`, "refers to itself"},
+	}
+	for _, test := range tests {
+		_, err := parseWhere(test.expr, queries)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("parseWhere(%q) error = %v, want %q", test.expr, err, test.want)
+		}
+	}
+}